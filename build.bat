@@ -10,4 +10,4 @@ start "" ".\weather-warnings.exe" --watch -i 30 -o warnings.html -v
 
 timeout /t 5 
 
-start warnings.html
+start http://localhost:8085/
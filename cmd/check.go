@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/coverage"
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+	"github.com/Zachdehooge/warnings-dashboard/internal/rules"
+	"github.com/spf13/cobra"
+)
+
+func addCheckCmd(rootCmd *cobra.Command) {
+	var (
+		loc      coverage.Location
+		allRules bool
+		noLookup bool
+		asJSON   bool
+	)
+
+	checkCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check whether a location is under an active alert",
+		Long: `Check a latitude/longitude against active alerts. Alerts with a polygon are
+tested geometrically; alerts without one (most watches) are matched on the
+location's county and zone codes, looked up from the NWS points endpoint
+unless --ugc or --same are given.
+
+By default only alerts allowed by the filter rules are considered; --all
+checks every active alert.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !cmd.Flags().Changed("lat") || !cmd.Flags().Changed("lon") {
+				cmd.PrintErrln("--lat and --lon are required")
+				os.Exit(1)
+			}
+			if loc.Lat < -90 || loc.Lat > 90 || loc.Lon < -180 || loc.Lon > 180 {
+				cmd.PrintErrln("--lat must be within ±90 and --lon within ±180")
+				os.Exit(1)
+			}
+			loc.NormalizeCodes()
+			if !noLookup && len(loc.UGC) == 0 && len(loc.SAME) == 0 {
+				if err := coverage.NewResolver(cfg.NWS).Resolve(&loc); err != nil {
+					cmd.PrintErrln(fmt.Errorf("zone lookup failed, checking polygons only: %w", err))
+				}
+			}
+
+			var rs *rules.Set
+			if !allRules {
+				rs = ruleStore.Current()
+			}
+			warnings, err := fetcher.FetchWarnings(alertSource, rs)
+			if err != nil {
+				cmd.PrintErrln(fmt.Errorf("failed to fetch warnings: %w", err))
+				os.Exit(1)
+			}
+			result := coverage.CheckLocation(warnings, loc)
+			if asJSON {
+				writeJSON(cmd, result)
+				return
+			}
+
+			out := cmd.OutOrStdout()
+			matches := result.Matches
+			if len(matches) == 0 {
+				fmt.Fprintf(out, "%.4f,%.4f is not under any active alert.\n", loc.Lat, loc.Lon)
+				return
+			}
+			fmt.Fprintf(out, "%.4f,%.4f is under %d active alert(s):\n", loc.Lat, loc.Lon, len(matches))
+			tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "TYPE\tSEVERITY\tMATCHED BY\tEXPIRES\tAREA")
+			for _, m := range matches {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", m.Alert.Type, m.Alert.Severity, m.By, m.Alert.ExpiresTime, m.Alert.Area)
+			}
+			tw.Flush()
+		},
+	}
+
+	f := checkCmd.Flags()
+	f.Float64Var(&loc.Lat, "lat", 0, "Latitude of the location")
+	f.Float64Var(&loc.Lon, "lon", 0, "Longitude of the location")
+	f.StringSliceVar(&loc.UGC, "ugc", nil, "County or zone UGC codes of the location, e.g. ALC073,ALZ024")
+	f.StringSliceVar(&loc.SAME, "same", nil, "SAME or county FIPS codes of the location, e.g. 01073")
+	f.BoolVar(&allRules, "all", false, "Check every active alert, ignoring the filter rules")
+	f.BoolVar(&noLookup, "no-lookup", false, "Don't look up the location's zones from NWS")
+	f.BoolVar(&asJSON, "json", false, "Print the result as JSON")
+
+	rootCmd.AddCommand(checkCmd)
+}
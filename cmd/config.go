@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/config"
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+	"github.com/Zachdehooge/warnings-dashboard/internal/geo"
+	"github.com/Zachdehooge/warnings-dashboard/internal/rules"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configFile string
+	rulesFile  string
+	historyDB  string
+	sourceSpec string
+	recordDir  string
+	replaySpd  float64
+	replayLoop bool
+
+	nwsURL    string
+	contact   string
+	userAgent string
+	areas     []string
+	zones     []string
+	region    string
+	point     string
+	events    []string
+	mcdURL    string
+	spcURL    string
+	webDir    string
+	mbtiles   string
+	tileCache string
+
+	cfg *config.Config
+
+	regionOnce   sync.Once
+	regionBounds *geo.BBox
+)
+
+// addConfigFlags registers the flags shared by every command. Flags that are
+// set explicitly override the matching value in the --config file.
+func addConfigFlags(rootCmd *cobra.Command) {
+	f := rootCmd.PersistentFlags()
+	f.StringVar(&configFile, "config", "", "YAML configuration file")
+	f.StringVar(&rulesFile, "rules", "", "Alert filter rules file (YAML or JSON); built-in rules when empty")
+	f.StringVar(&historyDB, "history", "", "SQLite database that keeps every alert and MCD seen in watch mode")
+	f.StringVar(&sourceSpec, "source", "nws", "Alert source: nws, file:<path> (GeoJSON file or directory) or replay:<dir>")
+	f.StringVar(&recordDir, "record", "", "Directory to save a snapshot of every live NWS fetch, for later replay")
+	f.Float64Var(&replaySpd, "replay-speed", 1, "Playback speed multiplier for replay sources")
+	f.BoolVar(&replayLoop, "replay-loop", false, "Restart a replay after its last snapshot")
+
+	f.StringVar(&nwsURL, "nws-url", fetcher.DefaultBaseURL, "NWS API base URL (e.g. a caching proxy or local stub)")
+	f.StringVar(&contact, "contact", "", "Contact email or URL sent in the NWS User-Agent")
+	f.StringVar(&userAgent, "user-agent", "", "Override the User-Agent sent to NWS and SPC")
+	f.StringSliceVar(&areas, "area", nil, "Only request alerts for these state/marine area codes (NWS area=)")
+	f.StringSliceVar(&zones, "zone", nil, "Only request alerts for these zone IDs (NWS zone=)")
+	f.StringVar(&region, "region", "", "Only request alerts for this marine region (NWS region=)")
+	f.StringVar(&point, "point", "", "Only request alerts covering this lat,lon (NWS point=)")
+	f.StringSliceVar(&events, "events", nil, "Only request these NWS event names; all events when empty")
+	f.StringVar(&mcdURL, "mcd-url", "", "SPC mesoscale discussion MapServer query URL")
+	f.StringVar(&spcURL, "spc-url", "", "SPC mesoscale discussion product page base URL")
+	f.StringVar(&mbtiles, "mbtiles", "", "Serve the map basemap from this MBTiles file, for offline use")
+	f.StringVar(&tileCache, "tile-cache", "", `Directory the tile proxy caches basemap and radar tiles in; "off" disables the cache`)
+	f.StringVar(&webDir, "web-dir", "", "Serve the page templates and assets from this directory (laid out like internal/web) instead of the built-in copies")
+}
+
+// setup loads the configuration, rules and alert source before any command runs.
+func setup(cmd *cobra.Command, args []string) error {
+	c, err := config.Load(configFile)
+	if err != nil {
+		return err
+	}
+	applyFlagOverrides(cmd, c)
+	cfg = c
+
+	store, err := rules.NewStore(cfg.Rules)
+	if err != nil {
+		return fmt.Errorf("failed to load rules: %w", err)
+	}
+	ruleStore = store
+
+	src, err := newAlertSource(sourceSpec)
+	if err != nil {
+		return err
+	}
+	alertSource = src
+	return nil
+}
+
+// pageBounds returns the map extent of the area, zone or point filter, or
+// nil when the dashboard covers the whole feed. A zone filter is looked up
+// from the NWS API, so this is only done for the commands that draw a map,
+// the first time one asks.
+func pageBounds() *geo.BBox {
+	regionOnce.Do(func() {
+		if b, ok := cfg.NWS.Bounds(); ok {
+			regionBounds = &b
+		}
+	})
+	return regionBounds
+}
+
+func applyFlagOverrides(cmd *cobra.Command, c *config.Config) {
+	changed := cmd.Flags().Changed
+	if changed("rules") {
+		c.Rules = rulesFile
+	}
+	// listen is a root-command flag, so only fall back to its default when
+	// neither the flag nor the file set it.
+	if changed("listen") || c.Listen == "" {
+		c.Listen = listenAddr
+	}
+	if changed("history") {
+		c.History = historyDB
+	}
+	if changed("nws-url") {
+		c.NWS.BaseURL = nwsURL
+	}
+	if changed("contact") {
+		c.NWS.Contact = contact
+	}
+	if changed("user-agent") {
+		c.NWS.UserAgent = userAgent
+	}
+	if changed("area") {
+		c.NWS.Area = areas
+	}
+	if changed("zone") {
+		c.NWS.Zone = zones
+	}
+	if changed("region") {
+		c.NWS.Region = region
+	}
+	if changed("point") {
+		c.NWS.Point = point
+	}
+	if changed("events") {
+		c.NWS.Events = events
+	}
+	if changed("mcd-url") {
+		c.SPC.MapServerURL = mcdURL
+	}
+	if changed("spc-url") {
+		c.SPC.ProductURL = spcURL
+	}
+	if changed("web-dir") {
+		c.Web.Dir = webDir
+	}
+	if changed("mbtiles") {
+		c.Tiles.MBTiles = mbtiles
+	}
+	if changed("tile-cache") {
+		c.Tiles.CacheDir = tileCache
+	}
+	c.SPC.UserAgent = c.NWS.UserAgentString()
+}
+
+// newAlertSource builds the alert source named by a --source value.
+func newAlertSource(spec string) (fetcher.AlertSource, error) {
+	kind, path, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "nws":
+		src, err := fetcher.NewNWSSource(cfg.NWS)
+		if err != nil {
+			return nil, err
+		}
+		src.Record = recordDir
+		return src, nil
+	case "file":
+		if path == "" {
+			return nil, fmt.Errorf("--source file: needs a path")
+		}
+		return &fetcher.FileSource{Path: path}, nil
+	case "replay":
+		if path == "" {
+			return nil, fmt.Errorf("--source replay: needs a directory")
+		}
+		return &fetcher.ReplaySource{Dir: path, Speed: replaySpd, Loop: replayLoop}, nil
+	default:
+		return nil, fmt.Errorf("unknown alert source %q", spec)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/history"
+	"github.com/spf13/cobra"
+)
+
+func addHistoryCmd(rootCmd *cobra.Command) {
+	var (
+		from, to string
+		q        history.Query
+		mcds     bool
+		asJSON   bool
+	)
+
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "Query alerts and MCDs recorded in the history database",
+		Long: `Query the database written by watch mode when --history is set. An alert
+matches a date range if it was active at any point inside it.
+
+Dates are RFC 3339 timestamps or plain YYYY-MM-DD days (UTC).`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if cfg.History == "" {
+				cmd.PrintErrln("no history database: set --history or history: in the config file")
+				os.Exit(1)
+			}
+
+			var err error
+			if q.From, err = parseHistoryTime(from, false); err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			if q.To, err = parseHistoryTime(to, true); err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+
+			store, err := history.Open(cfg.History)
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			defer store.Close()
+
+			out := cmd.OutOrStdout()
+			tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+			if mcds {
+				records, err := store.MCDs(q)
+				if err != nil {
+					cmd.PrintErrln(err)
+					os.Exit(1)
+				}
+				if asJSON {
+					writeJSON(cmd, records)
+					return
+				}
+				fmt.Fprintln(tw, "FIRST SEEN\tLAST SEEN\tMCD")
+				for _, r := range records {
+					fmt.Fprintf(tw, "%s\t%s\t%s\n", formatSeen(r.FirstSeen), formatSeen(r.LastSeen), r.MCD.Name)
+				}
+				tw.Flush()
+				return
+			}
+
+			records, err := store.Alerts(q)
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			if asJSON {
+				writeJSON(cmd, records)
+				return
+			}
+			fmt.Fprintln(tw, "FIRST SEEN\tLAST SEEN\tTYPE\tSEVERITY\tAREA")
+			for _, r := range records {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+					formatSeen(r.FirstSeen), formatSeen(r.LastSeen), r.Alert.Type, r.Alert.Severity, r.Alert.Area)
+			}
+			tw.Flush()
+		},
+	}
+
+	f := historyCmd.Flags()
+	f.StringVar(&from, "from", "", "Only records seen at or after this time")
+	f.StringVar(&to, "to", "", "Only records seen at or before this time (a plain date includes the whole day)")
+	f.StringSliceVar(&q.Types, "type", nil, "Only these event types, e.g. \"Tornado Warning\"")
+	f.StringSliceVar(&q.States, "state", nil, "Only alerts with a UGC code in these states")
+	f.StringSliceVar(&q.UGC, "ugc", nil, "Only alerts covering these UGC codes, e.g. ALC073")
+	f.BoolVar(&mcds, "mcds", false, "List mesoscale discussions instead of alerts")
+	f.BoolVar(&asJSON, "json", false, "Print full records as JSON")
+
+	rootCmd.AddCommand(historyCmd)
+}
+
+// parseHistoryTime accepts an RFC 3339 timestamp or a YYYY-MM-DD date. With
+// endOfDay set, a date means the last second of that day.
+func parseHistoryTime(s string, endOfDay bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use RFC 3339 or YYYY-MM-DD", s)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Second)
+	}
+	return t, nil
+}
+
+func formatSeen(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04Z")
+}
+
+func writeJSON(cmd *cobra.Command, v interface{}) {
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		cmd.PrintErrln(fmt.Errorf("failed to encode JSON: %w", err))
+		os.Exit(1)
+	}
+}
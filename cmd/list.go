@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+	"github.com/Zachdehooge/warnings-dashboard/internal/generator"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// areaWidth is how much of an alert's area the compact table shows.
+const areaWidth = 60
+
+// listFilter selects alerts for the list command. Empty fields match
+// everything.
+type listFilter struct {
+	types      []string
+	severities []string
+	states     []string
+	since      time.Time
+}
+
+func addListCmd(rootCmd *cobra.Command) {
+	var (
+		output string
+		since  string
+		f      listFilter
+	)
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List active weather warnings",
+		Long: `List the active alerts allowed by the filter rules, in the same order as the
+dashboard: emergencies and PDS alerts first, then by type, hazard and severity.
+
+--since takes a duration such as 2h, an RFC 3339 timestamp or a YYYY-MM-DD day,
+and keeps alerts issued at or after it.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			switch output {
+			case "table", "wide", "json", "yaml":
+			default:
+				cmd.PrintErrln(fmt.Errorf("unknown output %q: use table, wide, json or yaml", output))
+				os.Exit(1)
+			}
+			now := fetcher.Now(alertSource)
+			if since != "" {
+				t, err := parseSince(since, now)
+				if err != nil {
+					cmd.PrintErrln(err)
+					os.Exit(1)
+				}
+				f.since = t
+			}
+
+			warnings, err := fetcher.FetchWarnings(alertSource, ruleStore.Current())
+			if err != nil {
+				cmd.PrintErrln(fmt.Errorf("failed to fetch warnings: %w", err))
+				os.Exit(1)
+			}
+			kept := []fetcher.Warning{}
+			for _, w := range warnings {
+				if f.match(w) {
+					kept = append(kept, w)
+				}
+			}
+			kept = generator.SortWarnings(kept)
+
+			switch output {
+			case "json":
+				writeJSON(cmd, kept)
+			case "yaml":
+				writeYAML(cmd, kept)
+			default:
+				if len(kept) == 0 {
+					cmd.Println("No active weather warnings.")
+					return
+				}
+				writeAlertTable(cmd, kept, now, output == "wide")
+			}
+		},
+	}
+
+	fl := listCmd.Flags()
+	fl.StringVarP(&output, "output", "o", "table", "Output format: table, wide, json or yaml")
+	fl.StringSliceVar(&f.types, "type", nil, "Only alerts whose event name contains one of these, e.g. \"tornado warning\"")
+	fl.StringSliceVar(&f.severities, "severity", nil, "Only alerts of these severities, e.g. Extreme,Severe")
+	fl.StringSliceVar(&f.states, "state", nil, "Only alerts with a zone in these states, e.g. AL,GA")
+	fl.StringVar(&since, "since", "", "Only alerts issued since a duration ago (2h), a time or a day")
+
+	rootCmd.AddCommand(listCmd)
+}
+
+func (f listFilter) match(w fetcher.Warning) bool {
+	if len(f.types) > 0 && !containsFold(w.Type, f.types) {
+		return false
+	}
+	if len(f.severities) > 0 && !equalFoldAny(w.Severity, f.severities) {
+		return false
+	}
+	if len(f.states) > 0 {
+		found := false
+		for _, code := range w.UGC {
+			if len(code) >= 2 && equalFoldAny(code[:2], f.states) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !f.since.IsZero() {
+		issued, err := time.Parse(time.RFC3339, w.Time)
+		if err != nil || issued.Before(f.since) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(s string, subs []string) bool {
+	s = strings.ToLower(s)
+	for _, sub := range subs {
+		if strings.Contains(s, strings.ToLower(strings.TrimSpace(sub))) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalFoldAny(s string, values []string) bool {
+	for _, v := range values {
+		if strings.EqualFold(s, strings.TrimSpace(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSince accepts a duration before now or an absolute time.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := parseHistoryTime(s, false)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q: use a duration such as 2h, RFC 3339 or YYYY-MM-DD", s)
+	}
+	return t, nil
+}
+
+// writeAlertTable prints one line per alert. The wide table adds issue and
+// expiry times, states, hazard tags and the alert ID, and shows the full area.
+func writeAlertTable(cmd *cobra.Command, warnings []fetcher.Warning, now time.Time, wide bool) {
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	if wide {
+		fmt.Fprintln(tw, "TYPE\tSEVERITY\tEXPIRES IN\tISSUED\tEXPIRES\tSTATES\tHAZARDS\tAREA\tID")
+	} else {
+		fmt.Fprintln(tw, "TYPE\tSEVERITY\tEXPIRES IN\tAREA")
+	}
+	for _, w := range warnings {
+		left := expiresIn(w.ExpiresTime, now)
+		if !wide {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", w.DisplayType(), w.Severity, left, truncate(w.Area, areaWidth))
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", w.DisplayType(), w.Severity, left,
+			localTime(w.Time), localTime(w.ExpiresTime), strings.Join(alertStateCodes(w), ","),
+			orDash(strings.Join(hazardSummary(w), ", ")), w.Area, w.ID)
+	}
+	tw.Flush()
+}
+
+// expiresIn formats the time left before an alert expires, e.g. 1h05m.
+func expiresIn(expires string, now time.Time) string {
+	t, err := time.Parse(time.RFC3339, expires)
+	if err != nil {
+		return "-"
+	}
+	left := t.Sub(now)
+	if left <= 0 {
+		return "expired"
+	}
+	left = left.Round(time.Minute)
+	if left < time.Hour {
+		return fmt.Sprintf("%dm", int(left.Minutes()))
+	}
+	if left < 48*time.Hour {
+		return fmt.Sprintf("%dh%02dm", int(left.Hours()), int(left.Minutes())%60)
+	}
+	return fmt.Sprintf("%dd", int(left.Hours())/24)
+}
+
+func localTime(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return "-"
+	}
+	return t.Local().Format("Jan 2 15:04 MST")
+}
+
+func alertStateCodes(w fetcher.Warning) []string {
+	var states []string
+	seen := map[string]bool{}
+	for _, code := range w.UGC {
+		if len(code) >= 2 && !seen[code[:2]] {
+			seen[code[:2]] = true
+			states = append(states, code[:2])
+		}
+	}
+	return states
+}
+
+func hazardSummary(w fetcher.Warning) []string {
+	var tags []string
+	if w.TornadoDetection != "" {
+		tags = append(tags, "tornado "+strings.ToLower(w.TornadoDetection))
+	}
+	if w.TornadoDamageThreat != "" {
+		tags = append(tags, strings.ToLower(w.TornadoDamageThreat))
+	}
+	if w.ThunderstormDamageThreat != "" {
+		tags = append(tags, strings.ToLower(w.ThunderstormDamageThreat))
+	}
+	if w.MaxHailSize > 0 {
+		tags = append(tags, fmt.Sprintf(`%.2f" hail`, w.MaxHailSize))
+	}
+	if w.MaxWindGust > 0 {
+		tags = append(tags, fmt.Sprintf("%d mph", w.MaxWindGust))
+	}
+	return tags
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// writeYAML prints v as YAML with the same field names as the JSON output.
+func writeYAML(cmd *cobra.Command, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		cmd.PrintErrln(fmt.Errorf("failed to encode YAML: %w", err))
+		os.Exit(1)
+	}
+	// JSON is YAML; decoding into a node keeps the field order, and
+	// clearing the JSON styles prints block style with plain scalars.
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		cmd.PrintErrln(fmt.Errorf("failed to encode YAML: %w", err))
+		os.Exit(1)
+	}
+	blockStyle(&node)
+	out, err := yaml.Marshal(&node)
+	if err != nil {
+		cmd.PrintErrln(fmt.Errorf("failed to encode YAML: %w", err))
+		os.Exit(1)
+	}
+	cmd.OutOrStdout().Write(out)
+}
+
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
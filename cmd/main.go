@@ -1,14 +1,27 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/Zachdehooge/warnings-dashboard/internal/coverage"
+	"github.com/Zachdehooge/warnings-dashboard/internal/export"
 	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
 	"github.com/Zachdehooge/warnings-dashboard/internal/generator"
+	"github.com/Zachdehooge/warnings-dashboard/internal/history"
+	"github.com/Zachdehooge/warnings-dashboard/internal/notify"
+	"github.com/Zachdehooge/warnings-dashboard/internal/rules"
+	"github.com/Zachdehooge/warnings-dashboard/internal/server"
+	"github.com/Zachdehooge/warnings-dashboard/internal/tiles"
+	"github.com/Zachdehooge/warnings-dashboard/internal/web"
 	"github.com/spf13/cobra"
 )
 
@@ -17,6 +30,17 @@ var (
 	verbose    bool
 	interval   int
 	watchMode  bool
+	listenAddr string
+	formats    []string
+
+	ruleStore   *rules.Store
+	alertSource fetcher.AlertSource
+	// srv serves the page and payload from memory in watch mode; nil when
+	// the built-in server is off.
+	srv *server.Server
+	// tileProxy serves the map's tiles through srv; nil when pages load
+	// them from upstream.
+	tileProxy *tiles.Proxy
 )
 
 func main() {
@@ -25,9 +49,35 @@ func main() {
 		Short: "Fetch and generate weather warnings HTML",
 		Long: `Weather Warnings CLI fetches active weather warnings 
 from the National Weather Service and generates a static HTML page.`,
+		PersistentPreRunE: setup,
 		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			if formats, err = export.ParseFormats(formats); err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			if watchMode && cfg.Listen != "off" {
+				srv = server.New()
+				srv.Resolver = coverage.NewResolver(cfg.NWS)
+				static, err := web.Static(cfg.Web.Files())
+				if err != nil {
+					cmd.PrintErrln(err)
+					os.Exit(1)
+				}
+				srv.SetStatic(static, cfg.Web.Dir != "")
+				if !cfg.Tiles.Direct {
+					if tileProxy, err = tiles.New(cfg.Tiles, cfg.Web.Basemap()); err != nil {
+						cmd.PrintErrln(err)
+						os.Exit(1)
+					}
+					tileProxy.Now = func() time.Time { return fetcher.Now(alertSource) }
+					tileProxy.UserAgent = cfg.NWS.UserAgentString()
+					srv.Tiles = tileProxy
+				}
+			}
+
 			// Generate warnings HTML
-			err := generateWarningsHTML(cmd)
+			err = generateWarningsHTML(cmd)
 			if err != nil {
 				cmd.PrintErrln(fmt.Errorf("failed to generate warnings: %w", err))
 				os.Exit(1)
@@ -35,6 +85,7 @@ from the National Weather Service and generates a static HTML page.`,
 
 			// Watch mode
 			if watchMode {
+				watchRules(cmd)
 				startHTTPServer(cmd)
 				runWatchMode(cmd)
 			}
@@ -46,9 +97,17 @@ from the National Weather Service and generates a static HTML page.`,
 	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
 	rootCmd.Flags().IntVarP(&interval, "interval", "i", 300, "Update interval in seconds (minimum 30)")
 	rootCmd.Flags().BoolVar(&watchMode, "watch", false, "Continuously update the warnings HTML")
+	rootCmd.Flags().StringSliceVar(&formats, "format", nil, "Also write the alerts beside the HTML file in these formats: "+strings.Join(export.Formats, ", "))
+	rootCmd.Flags().StringVar(&listenAddr, "listen", ":8085", `Address the watch-mode HTTP server listens on; "off" writes warnings.json to disk instead`)
+	addConfigFlags(rootCmd)
 
 	// Additional commands
 	addListCmd(rootCmd)
+	addRulesCmd(rootCmd)
+	addHistoryCmd(rootCmd)
+	addCheckCmd(rootCmd)
+	addNotifyTestCmd(rootCmd)
+	addTuiCmd(rootCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -60,7 +119,13 @@ func generateWarningsHTML(cmd *cobra.Command) error {
 		cmd.Println("Fetching active weather warnings...")
 	}
 
-	warnings, err := fetcher.FetchWarnings()
+	if reloaded, err := ruleStore.ReloadIfChanged(); err != nil {
+		cmd.PrintErrln(fmt.Errorf("keeping previous rules: %w", err))
+	} else if reloaded {
+		cmd.Println(fmt.Sprintf("Reloaded rules from %s", ruleStore.Path()))
+	}
+
+	warnings, err := fetcher.FetchWarnings(alertSource, ruleStore.Current())
 	if err != nil {
 		return fmt.Errorf("failed to fetch warnings: %w", err)
 	}
@@ -69,12 +134,52 @@ func generateWarningsHTML(cmd *cobra.Command) error {
 		cmd.Println(fmt.Sprintf("Generating HTML to %s...", outputFile))
 	}
 
-	err = generator.GenerateWarningsHTML(warnings, outputFile)
+	opts := generator.PageOptions{
+		Now:    fetcher.Now(alertSource),
+		Bounds: pageBounds(),
+		Web:    cfg.Web,
+		Tiles:  cfg.Tiles,
+		Proxy:  tileProxy,
+	}
+	page, err := generator.RenderWarningsHTML(warnings, opts)
 	if err != nil {
 		return fmt.Errorf("failed to generate HTML: %w", err)
 	}
+	if err := os.WriteFile(outputFile, page, 0644); err != nil {
+		return fmt.Errorf("failed to write HTML: %w", err)
+	}
+	// The served page links to the assets the server hosts instead of
+	// carrying them, so browsers can cache them between updates.
+	if srv != nil {
+		opts.Served = true
+		page, err := generator.RenderWarningsHTML(warnings, opts)
+		if err != nil {
+			return fmt.Errorf("failed to generate HTML: %w", err)
+		}
+		srv.SetPage(page)
+	}
 
 	cmd.Println(fmt.Sprintf("Weather warnings saved to %s", outputFile))
+	return writeExports(cmd, warnings)
+}
+
+// writeExports writes warnings in each --format beside the HTML file, e.g.
+// warnings.kml next to warnings.html.
+func writeExports(cmd *cobra.Command, warnings []fetcher.Warning) error {
+	base := strings.TrimSuffix(outputFile, filepath.Ext(outputFile))
+	for _, format := range formats {
+		var buf bytes.Buffer
+		if err := export.Encode(&buf, format, warnings, export.Options{Updated: fetcher.Now(alertSource)}); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", format, err)
+		}
+		path := base + export.Extension(format)
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		if verbose {
+			cmd.Println(fmt.Sprintf("Wrote %s", path))
+		}
+	}
 	return nil
 }
 
@@ -83,9 +188,54 @@ func runWatchMode(cmd *cobra.Command) {
 		interval = 30
 	}
 
-	jsonPath := filepath.Join(filepath.Dir(outputFile), "warnings.json")
-	generator.StartPoller(jsonPath, 15*time.Second)
-	cmd.Println(fmt.Sprintf("Poller started — writing %s every 15s", jsonPath))
+	_, live := alertSource.(*fetcher.NWSSource)
+	poller := &generator.Poller{
+		Source:    alertSource,
+		Rules:     ruleStore,
+		Interval:  15 * time.Second,
+		SkipMCDs:  !live,
+		MCD:       cfg.SPC,
+		Bounds:    pageBounds(),
+		Locations: watchedLocations(),
+	}
+	notifiers, err := newNotifiers()
+	if err != nil {
+		cmd.PrintErrln(err)
+		os.Exit(1)
+	}
+	poller.Notifiers = notifiers
+	// The built-in server holds the payload in memory; without it, write
+	// warnings.json beside the page for whatever server hosts the directory.
+	if srv != nil {
+		poller.Publish = srv.Publish
+		poller.PublishDelta = srv.PushDelta
+	} else {
+		poller.OutputPath = filepath.Join(filepath.Dir(outputFile), "warnings.json")
+		// Keep the --format files as fresh as warnings.json beside them.
+		if len(formats) > 0 {
+			poller.Publish = func(p *generator.PolledPayload, _ []byte) {
+				if err := writeExports(cmd, p.Warnings); err != nil {
+					log.Printf("[poller] %v", err)
+				}
+			}
+		}
+	}
+	if cfg.History != "" {
+		store, err := history.Open(cfg.History)
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+		defer store.Close()
+		poller.History = store
+		cmd.Println(fmt.Sprintf("Recording alert history to %s", cfg.History))
+	}
+	poller.Start()
+	if poller.OutputPath != "" {
+		cmd.Println(fmt.Sprintf("Poller started — writing %s every 15s", poller.OutputPath))
+	} else {
+		cmd.Println("Poller started — serving warnings.json from memory every 15s")
+	}
 
 	cmd.Println(fmt.Sprintf("Watch mode activated. Updating every %d seconds. Press Ctrl+C to stop.", interval))
 
@@ -100,55 +250,98 @@ func runWatchMode(cmd *cobra.Command) {
 	}
 }
 
-func startHTTPServer(cmd *cobra.Command) {
-	dir := filepath.Dir(outputFile)
-	fs := http.FileServer(http.Dir(dir))
-
-	noCache := func(h http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0")
-			w.Header().Set("Pragma", "no-cache")
-			w.Header().Set("Expires", "0")
-			h.ServeHTTP(w, r)
-		})
-	}(fs)
+// watchedLocations returns the configured locations with their codes
+// normalised, looking up the zones of any that list none.
+func watchedLocations() []coverage.Location {
+	locs := make([]coverage.Location, len(cfg.Locations))
+	resolver := coverage.NewResolver(cfg.NWS)
+	for i, loc := range cfg.Locations {
+		loc.NormalizeCodes()
+		if len(loc.UGC) == 0 && len(loc.SAME) == 0 {
+			if err := resolver.Resolve(&loc); err != nil {
+				log.Printf("[locations] %s: zone lookup failed, matching polygons only: %v", loc.Name, err)
+			}
+		}
+		locs[i] = loc
+	}
+	return locs
+}
 
-	go func() {
-		addr := ":8085"
-		cmd.Println("Starting HTTP server at http://localhost:8085/")
-		err := http.ListenAndServe(addr, noCache)
+// newNotifiers builds the notification targets from the notify: section of
+// the config file.
+func newNotifiers() ([]generator.Notifier, error) {
+	targets, err := notifyTargets()
+	if err != nil || len(targets) == 0 {
+		return nil, err
+	}
+	return []generator.Notifier{notify.NewDispatcher(targets...)}, nil
+}
+
+func notifyTargets() ([]notify.Target, error) {
+	var targets []notify.Target
+	for _, wc := range cfg.Notify.Webhooks {
+		w, err := notify.NewWebhook(wc)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, w)
+	}
+	for _, ec := range cfg.Notify.Email {
+		e, err := notify.NewEmail(ec)
 		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, e)
+	}
+	return targets, nil
+}
+
+// watchRules reloads the rule file whenever the process receives SIGHUP.
+// Edits are also picked up on the next update cycle without a signal.
+func watchRules(cmd *cobra.Command) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	go func() {
+		for range sig {
+			if err := ruleStore.Reload(); err != nil {
+				cmd.PrintErrln(fmt.Errorf("rule reload failed: %w", err))
+				continue
+			}
+			cmd.Println("Rules reloaded")
+		}
+	}()
+}
+
+// startHTTPServer serves the in-memory page and payload on the configured
+// listen address.
+func startHTTPServer(cmd *cobra.Command) {
+	if srv == nil {
+		return
+	}
+	addr := cfg.Listen
+	go func() {
+		cmd.Println(fmt.Sprintf("Starting HTTP server on %s", addr))
+		if err := http.ListenAndServe(addr, srv.Handler()); err != nil {
 			cmd.PrintErrln(fmt.Errorf("failed to start HTTP server: %w", err))
 		}
 	}()
 }
 
-func addListCmd(rootCmd *cobra.Command) {
-	listCmd := &cobra.Command{
-		Use:   "list",
-		Short: "List active weather warnings",
+func addRulesCmd(rootCmd *cobra.Command) {
+	rulesCmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Print the active alert filter rules",
+		Long: `Print the alert filter rules in effect as YAML. Without --rules this
+prints the built-in rules, which makes a starting point for a rules file.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			warnings, err := fetcher.FetchWarnings()
+			data, err := ruleStore.Current().Marshal()
 			if err != nil {
-				cmd.PrintErrln(fmt.Errorf("failed to fetch warnings: %w", err))
+				cmd.PrintErrln(fmt.Errorf("failed to encode rules: %w", err))
 				os.Exit(1)
 			}
-
-			if len(warnings) == 0 {
-				cmd.Println("No active weather warnings.")
-				return
-			}
-
-			cmd.Println("Active Weather Warnings:")
-			for _, warning := range warnings {
-				cmd.Println("---")
-				cmd.Println(fmt.Sprintf("Type: %s", warning.Type))
-				cmd.Println(fmt.Sprintf("Severity: %s", warning.Severity))
-				cmd.Println(fmt.Sprintf("Area: %s", warning.Area))
-				cmd.Println(fmt.Sprintf("Description: %s", warning.Description))
-			}
+			fmt.Fprint(cmd.OutOrStdout(), string(data))
 		},
 	}
 
-	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(rulesCmd)
 }
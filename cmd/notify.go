@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+	"github.com/Zachdehooge/warnings-dashboard/internal/notify"
+	"github.com/spf13/cobra"
+)
+
+func addNotifyTestCmd(rootCmd *cobra.Command) {
+	notifyTestCmd := &cobra.Command{
+		Use:   "notify-test",
+		Short: "Send a sample alert to every configured notification target",
+		Long: `Send one sample Tornado Warning to every webhook and email target in the
+notify: section of the config file, ignoring their kinds and rules, and wait
+for delivery. Point a target at a local sink (for example an SMTP server on
+localhost:1025) to check the message layout.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			targets, err := notifyTargets()
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			if len(targets) == 0 {
+				cmd.PrintErrln("no notification targets: add webhooks or email under notify: in the config file")
+				os.Exit(1)
+			}
+
+			now := time.Now().UTC()
+			alert := fetcher.Warning{
+				ID:          "test",
+				Type:        "Tornado Warning",
+				Headline:    "Tornado Warning issued for Sample County (test message)",
+				Description: "This is a test of the warnings dashboard notifications.",
+				Instruction: "No action is needed.",
+				Area:        "Sample County",
+				Severity:    "Extreme",
+				SenderName:  "warnings-dashboard",
+				MessageType: "Alert",
+				Time:        now.Format(time.RFC3339),
+				ExpiresTime: now.Add(45 * time.Minute).Format(time.RFC3339),
+			}
+			m := notify.Message{
+				Key:   "test:" + now.Format(time.RFC3339),
+				Kind:  notify.KindNew,
+				Title: "Test: Tornado Warning issued for Sample County",
+				Alert: alert,
+				At:    now.Format(time.RFC3339),
+				Test:  true,
+			}
+			for _, t := range targets {
+				t.Send(m)
+				t.Close()
+			}
+			cmd.Println(fmt.Sprintf("Sent a test message to %d target(s); delivery errors are logged above", len(targets)))
+		},
+	}
+
+	rootCmd.AddCommand(notifyTestCmd)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+	"github.com/Zachdehooge/warnings-dashboard/internal/generator"
+	"github.com/Zachdehooge/warnings-dashboard/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+func addTuiCmd(rootCmd *cobra.Command) {
+	var logFile string
+
+	tuiCmd := &cobra.Command{
+		Use:   "tui",
+		Short: "Show a live dashboard in the terminal",
+		Long: `Show the dashboard in the terminal, for use over SSH. Alerts are polled every
+15 seconds like watch mode and grouped and coloured like the page, with
+expiry countdowns and a detail pane for the selected alert or mesoscale
+discussion.
+
+Keys: ↑/↓ or j/k move, PgUp/PgDn page, enter shows the full text, / filters
+by type, area, severity or zone, esc clears the filter, q quits.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Poller logs would scribble over the screen.
+			log.SetOutput(io.Discard)
+			if logFile != "" {
+				f, err := os.OpenFile(logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+				if err != nil {
+					cmd.PrintErrln(fmt.Errorf("failed to open log file: %w", err))
+					os.Exit(1)
+				}
+				defer f.Close()
+				log.SetOutput(f)
+			}
+
+			// Keep only the newest payload if the screen falls behind.
+			payloads := make(chan *generator.PolledPayload, 1)
+			failures := make(chan error, 1)
+			_, live := alertSource.(*fetcher.NWSSource)
+			poller := &generator.Poller{
+				Source:   alertSource,
+				Rules:    ruleStore,
+				Interval: 15 * time.Second,
+				SkipMCDs: !live,
+				MCD:      cfg.SPC,
+				Bounds:   pageBounds(),
+				Publish: func(p *generator.PolledPayload, _ []byte) {
+					select {
+					case <-payloads:
+					default:
+					}
+					payloads <- p
+				},
+				Failed: func(err error) {
+					select {
+					case <-failures:
+					default:
+					}
+					failures <- err
+				},
+			}
+			go poller.Start()
+
+			if err := tui.Run(payloads, failures); err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+		},
+	}
+	tuiCmd.Flags().StringVar(&logFile, "log", "", "Write poller logs to this file")
+
+	rootCmd.AddCommand(tuiCmd)
+}
@@ -0,0 +1,90 @@
+// Package boundaries holds simplified outlines of NWS counties, public
+// forecast zones, fire weather zones and marine zones, embedded in the
+// binary and keyed by UGC code, for drawing alerts that arrive without a
+// polygon. The data is built from the NWS shapefiles by go generate.
+package boundaries
+
+import (
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+)
+
+//go:generate go run ./gen -o boundaries.json.gz
+
+//go:embed boundaries.json.gz
+var embedded []byte
+
+// sets maps UGC codes to MultiPolygon coordinates, one map per zone type.
+// Fire weather zones reuse the Z codes of public zones, so they are kept
+// apart.
+type sets struct {
+	County map[string]json.RawMessage `json:"county"`
+	Zone   map[string]json.RawMessage `json:"zone"`
+	Fire   map[string]json.RawMessage `json:"fire"`
+	Marine map[string]json.RawMessage `json:"marine"`
+}
+
+var (
+	loadOnce sync.Once
+	loaded   sets
+)
+
+// data decodes the embedded outlines on first use.
+func data() *sets {
+	loadOnce.Do(func() {
+		zr, err := gzip.NewReader(bytes.NewReader(embedded))
+		if err == nil {
+			err = json.NewDecoder(zr).Decode(&loaded)
+		}
+		if err != nil {
+			log.Printf("[boundaries] failed to load embedded boundaries: %v", err)
+		} else if len(loaded.County)+len(loaded.Zone)+len(loaded.Fire)+len(loaded.Marine) == 0 {
+			log.Printf("[boundaries] no boundaries embedded; run go generate ./internal/boundaries to outline zone-based alerts")
+		}
+	})
+	return &loaded
+}
+
+// fireEvents are issued for fire weather zones rather than public zones.
+var fireEvents = map[string]bool{
+	"red flag warning":   true,
+	"fire weather watch": true,
+}
+
+// Lookup returns the outline of a county (ALC073), zone (ALZ024) or marine
+// zone (GMZ550) as MultiPolygon coordinates. The event decides whether a Z
+// code names a fire weather zone or a public zone.
+func Lookup(event, ugc string) ([][][][]float64, bool) {
+	ugc = strings.ToUpper(strings.TrimSpace(ugc))
+	if len(ugc) != 6 {
+		return nil, false
+	}
+	d := data()
+	var raw json.RawMessage
+	var ok bool
+	switch {
+	case ugc[2] == 'C':
+		raw, ok = d.County[ugc]
+	case fireEvents[strings.ToLower(event)]:
+		if raw, ok = d.Fire[ugc]; !ok {
+			raw, ok = d.Zone[ugc]
+		}
+	default:
+		if raw, ok = d.Zone[ugc]; !ok {
+			raw, ok = d.Marine[ugc]
+		}
+	}
+	if !ok {
+		return nil, false
+	}
+	var polygons [][][][]float64
+	if err := json.Unmarshal(raw, &polygons); err != nil || len(polygons) == 0 {
+		return nil, false
+	}
+	return polygons, true
+}
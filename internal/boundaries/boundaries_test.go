@@ -0,0 +1,83 @@
+package boundaries
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+// useOutlines stands in for the embedded data for the rest of the test.
+func useOutlines(t *testing.T, s map[string]map[string][][][][]float64) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(s); err != nil {
+		t.Fatal(err)
+	}
+	zw.Close()
+
+	saved := embedded
+	reset := func() {
+		loadOnce, loaded = sync.Once{}, sets{}
+		cacheMu.Lock()
+		cache = map[string][][][][]float64{}
+		cacheMu.Unlock()
+	}
+	embedded = buf.Bytes()
+	reset()
+	t.Cleanup(func() {
+		embedded = saved
+		reset()
+	})
+}
+
+func TestLookupChoosesTheSet(t *testing.T) {
+	square := func(x float64) [][][][]float64 {
+		return [][][][]float64{{{{x, 0}, {x + 1, 0}, {x + 1, 1}, {x, 1}, {x, 0}}}}
+	}
+	useOutlines(t, map[string]map[string][][][][]float64{
+		"county": {"ALC073": square(0)},
+		"zone":   {"ALZ024": square(1)},
+		"fire":   {"ALZ024": square(2)},
+		"marine": {"GMZ550": square(3)},
+	})
+
+	cases := []struct {
+		event, ugc string
+		x          float64
+		ok         bool
+	}{
+		{"Tornado Watch", "ALC073", 0, true},
+		{"Tornado Watch", " alc073 ", 0, true},
+		{"Winter Storm Warning", "ALZ024", 1, true},
+		{"Red Flag Warning", "ALZ024", 2, true},
+		{"Small Craft Advisory", "GMZ550", 3, true},
+		{"Tornado Watch", "ALC001", 0, false},
+		{"Tornado Watch", "ALC0731", 0, false},
+	}
+	for _, c := range cases {
+		got, ok := Lookup(c.event, c.ugc)
+		if ok != c.ok || (ok && got[0][0][0][0] != c.x) {
+			t.Errorf("Lookup(%q, %q) = %v, %v", c.event, c.ugc, got, ok)
+		}
+	}
+}
+
+func TestOutlineDissolvesListedCounties(t *testing.T) {
+	useOutlines(t, map[string]map[string][][][][]float64{
+		"county": {"ALC073": {west}, "ALC117": {east}},
+	})
+
+	out := Outline("Tornado Watch", []string{"ALC117", "alc073", "ALC073", "ALC999"})
+	if len(out) != 1 || len(out[0]) != 1 {
+		t.Fatalf("got %v, want the two counties as one ring", out)
+	}
+	if again := Outline("Tornado Watch", []string{"ALC999", "ALC073", "ALC117"}); len(again) != 1 || &again[0] != &out[0] {
+		t.Error("the same set of codes was not served from the cache")
+	}
+	if out := Outline("Tornado Watch", []string{"ALC999"}); out != nil {
+		t.Errorf("unknown county outlined as %v", out)
+	}
+}
@@ -0,0 +1,194 @@
+package boundaries
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/geo"
+)
+
+// maxCached bounds the outline cache. Active alerts rarely list more than a
+// few dozen distinct zone sets, so the cache is simply emptied when full.
+const maxCached = 256
+
+var (
+	cacheMu sync.Mutex
+	cache   = map[string][][][][]float64{}
+)
+
+// Outline returns the combined outline of the zones an alert lists, as
+// MultiPolygon coordinates: borders between the zones are dissolved, so a
+// watch over forty counties is one shape rather than forty. Results are
+// cached per set of codes. It returns nil when none of the codes has a
+// known outline.
+func Outline(event string, ugc []string) [][][][]float64 {
+	codes := make([]string, 0, len(ugc))
+	seen := map[string]bool{}
+	for _, c := range ugc {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if c != "" && !seen[c] {
+			seen[c] = true
+			codes = append(codes, c)
+		}
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	sort.Strings(codes)
+	key := strings.Join(codes, ",")
+	if fireEvents[strings.ToLower(event)] {
+		key = "fire:" + key
+	}
+
+	cacheMu.Lock()
+	polygons, ok := cache[key]
+	cacheMu.Unlock()
+	if ok {
+		return polygons
+	}
+
+	var parts [][][][]float64
+	for _, code := range codes {
+		if p, ok := Lookup(event, code); ok {
+			parts = append(parts, p...)
+		}
+	}
+	if len(parts) > 0 {
+		polygons = dissolve(parts)
+	}
+
+	cacheMu.Lock()
+	if len(cache) >= maxCached {
+		cache = map[string][][][][]float64{}
+	}
+	cache[key] = polygons
+	cacheMu.Unlock()
+	return polygons
+}
+
+type position [2]float64
+
+type edge struct{ from, to position }
+
+// dissolve merges polygons that share borders. The outlines are built so
+// that neighbouring zones trace identical vertices along their common
+// border, in opposite directions (outer rings counter-clockwise, holes
+// clockwise). Dropping every edge whose reverse is also present leaves only
+// the outside of the union, which is chained back into rings. Polygons that
+// only overlap, such as a county listed with a zone inside it, are left as
+// they are.
+func dissolve(polygons [][][][]float64) [][][][]float64 {
+	if len(polygons) < 2 {
+		return polygons
+	}
+
+	count := map[edge]int{}
+	var order []edge
+	for _, poly := range polygons {
+		for _, ring := range poly {
+			for i := 0; i+1 < len(ring); i++ {
+				if len(ring[i]) < 2 || len(ring[i+1]) < 2 {
+					continue
+				}
+				e := edge{position{ring[i][0], ring[i][1]}, position{ring[i+1][0], ring[i+1][1]}}
+				if e.from == e.to {
+					continue
+				}
+				if back := (edge{e.to, e.from}); count[back] > 0 {
+					count[back]--
+					continue
+				}
+				if count[e] == 0 {
+					order = append(order, e)
+				}
+				count[e]++
+			}
+		}
+	}
+
+	// Chain the remaining edges into rings, in the order they were seen so
+	// the result is stable.
+	next := map[position][]edge{}
+	for _, e := range order {
+		for i := 0; i < count[e]; i++ {
+			next[e.from] = append(next[e.from], e)
+		}
+	}
+	var rings [][][]float64
+	for _, start := range order {
+		for len(next[start.from]) > 0 && containsEdge(next[start.from], start) {
+			ring := [][]float64{{start.from[0], start.from[1]}}
+			at := start.from
+			e := start
+			for {
+				next[at] = removeEdge(next[at], e)
+				ring = append(ring, []float64{e.to[0], e.to[1]})
+				at = e.to
+				if at == start.from || len(next[at]) == 0 {
+					break
+				}
+				e = next[at][0]
+			}
+			if at != start.from || len(ring) < 4 {
+				// Not a closed ring: the inputs were not clean, so keep them.
+				return polygons
+			}
+			rings = append(rings, ring)
+		}
+	}
+
+	var outers, holes [][][]float64
+	for _, r := range rings {
+		if signedArea(r) > 0 {
+			outers = append(outers, r)
+		} else {
+			holes = append(holes, r)
+		}
+	}
+	out := make([][][][]float64, len(outers))
+	for i, o := range outers {
+		out[i] = [][][]float64{o}
+	}
+	for _, h := range holes {
+		placed := false
+		for i, o := range outers {
+			if geo.RingContains(o, h[0][0], h[0][1]) {
+				out[i] = append(out[i], h)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			return polygons
+		}
+	}
+	return out
+}
+
+func containsEdge(edges []edge, e edge) bool {
+	for _, x := range edges {
+		if x == e {
+			return true
+		}
+	}
+	return false
+}
+
+func removeEdge(edges []edge, e edge) []edge {
+	for i, x := range edges {
+		if x == e {
+			return append(edges[:i:i], edges[i+1:]...)
+		}
+	}
+	return edges
+}
+
+// signedArea is positive for counter-clockwise rings.
+func signedArea(ring [][]float64) float64 {
+	var a float64
+	for i := 0; i+1 < len(ring); i++ {
+		a += ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+	}
+	return a / 2
+}
@@ -0,0 +1,47 @@
+package boundaries
+
+import (
+	"math"
+	"testing"
+)
+
+// Two counties side by side, both tracing the midpoint of their common
+// border, as the generator's outlines do.
+var (
+	west = [][][]float64{{{0, 0}, {2, 0}, {2, 1}, {2, 2}, {0, 2}, {0, 0}}}
+	east = [][][]float64{{{2, 0}, {4, 0}, {4, 2}, {2, 2}, {2, 1}, {2, 0}}}
+)
+
+func TestDissolveAdjacentCounties(t *testing.T) {
+	out := dissolve([][][][]float64{west, east})
+	if len(out) != 1 || len(out[0]) != 1 {
+		t.Fatalf("got %d polygons, want one polygon with one ring: %v", len(out), out)
+	}
+	ring := out[0][0]
+	if first, last := ring[0], ring[len(ring)-1]; first[0] != last[0] || first[1] != last[1] {
+		t.Errorf("ring is not closed: %v", ring)
+	}
+	if a := signedArea(ring); math.Abs(a-8) > 1e-9 {
+		t.Errorf("area = %v, want 8 (counter-clockwise)", a)
+	}
+	for _, p := range ring {
+		if p[0] == 2 && p[1] == 1 {
+			t.Errorf("shared border point %v survived the dissolve: %v", p, ring)
+		}
+	}
+}
+
+func TestDissolveKeepsEnclave(t *testing.T) {
+	outer := [][][]float64{
+		{{0, 0}, {3, 0}, {3, 3}, {0, 3}, {0, 0}},
+		{{1, 1}, {1, 2}, {2, 2}, {2, 1}, {1, 1}},
+	}
+	far := [][][]float64{{{5, 0}, {6, 0}, {6, 1}, {5, 1}, {5, 0}}}
+	out := dissolve([][][][]float64{outer, far})
+	if len(out) != 2 {
+		t.Fatalf("got %d polygons, want 2: %v", len(out), out)
+	}
+	if len(out[0]) != 2 {
+		t.Errorf("enclave hole lost: %v", out[0])
+	}
+}
@@ -0,0 +1,167 @@
+// Command gen builds the boundaries package's embedded outlines from the
+// NWS county, zone, fire weather zone and marine zone shapefiles. Run it
+// with go generate ./internal/boundaries after the NWS publishes new
+// shapefiles, passing the new file names:
+//
+//	go run ./gen -county County/c_18mr25.zip -zone WSOM/z_18mr25.zip -o boundaries.json.gz
+//
+// Each flag takes a URL, a local path or a path on the NWS GIS site.
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+)
+
+const nwsShapefiles = "https://www.weather.gov/source/gis/Shapefiles/"
+
+// set is one kind of outline and how its records are keyed by UGC code.
+type set struct {
+	name string
+	srcs []string
+	key  func(attrs map[string]string) string
+}
+
+func main() {
+	var (
+		county    = flag.String("county", "County/c_05mr24.zip", "County shapefile")
+		zone      = flag.String("zone", "WSOM/z_05mr24.zip", "Public forecast zone shapefile")
+		fire      = flag.String("fire", "WSOM/fz05mr24.zip", "Fire weather zone shapefile")
+		marine    = flag.String("marine", "WSOM/mz05mr24.zip,WSOM/oz05mr24.zip", "Comma-separated coastal and offshore marine zone shapefiles")
+		out       = flag.String("o", "boundaries.json.gz", "Output file")
+		tolerance = flag.Float64("tolerance", 0.005, "Simplification tolerance in degrees")
+		precision = flag.Int("precision", 4, "Decimal places kept in coordinates")
+	)
+	flag.Parse()
+
+	zoneKey := func(a map[string]string) string {
+		if a["STATE"] == "" || a["ZONE"] == "" {
+			return ""
+		}
+		return a["STATE"] + "Z" + a["ZONE"]
+	}
+	sets := []set{
+		{"county", []string{*county}, func(a map[string]string) string {
+			// FIPS is the five-digit state and county code.
+			if a["STATE"] == "" || len(a["FIPS"]) != 5 {
+				return ""
+			}
+			return a["STATE"] + "C" + a["FIPS"][2:]
+		}},
+		{"zone", []string{*zone}, zoneKey},
+		{"fire", []string{*fire}, zoneKey},
+		{"marine", strings.Split(*marine, ","), func(a map[string]string) string {
+			return a["ID"]
+		}},
+	}
+
+	result := map[string]map[string][][][][]float64{}
+	for _, s := range sets {
+		shapes, err := build(s, *tolerance, *precision)
+		if err != nil {
+			log.Fatalf("%s: %v", s.name, err)
+		}
+		result[s.name] = shapes
+		log.Printf("%s: %d outlines", s.name, len(shapes))
+	}
+
+	if err := write(*out, result); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// build reads a set's shapefiles and returns its simplified outlines by
+// UGC code, merging records that share a code.
+func build(s set, tolerance float64, precision int) (map[string][][][][]float64, error) {
+	var records []record
+	for _, src := range s.srcs {
+		src = strings.TrimSpace(src)
+		if src == "" {
+			continue
+		}
+		if !strings.Contains(src, "://") {
+			if _, err := os.Stat(src); err != nil {
+				src = nwsShapefiles + src
+			}
+		}
+		rs, err := readZip(src)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rs...)
+	}
+
+	simp := newSimplifier(records, tolerance)
+	round := math.Pow(10, float64(precision))
+	shapes := map[string][][][][]float64{}
+	for _, r := range records {
+		code := strings.ToUpper(s.key(r.attrs))
+		if len(code) != 6 || len(r.rings) == 0 {
+			continue
+		}
+		var rings [][]point
+		for _, ring := range r.rings {
+			if ring = simp.ring(ring); ring != nil {
+				rings = append(rings, ring)
+			}
+		}
+		for _, poly := range polygons(rings) {
+			var out [][][]float64
+			for _, ring := range poly {
+				if coords := roundRing(ring, round); len(coords) >= 4 {
+					out = append(out, coords)
+				}
+			}
+			if len(out) > 0 {
+				shapes[code] = append(shapes[code], out)
+			}
+		}
+	}
+	return shapes, nil
+}
+
+// roundRing converts a ring to degrees at the output precision, dropping
+// points that round onto their predecessor.
+func roundRing(ring []point, round float64) [][]float64 {
+	out := make([][]float64, 0, len(ring))
+	for _, p := range ring {
+		c := []float64{
+			math.Round(float64(p.x)/scale*round) / round,
+			math.Round(float64(p.y)/scale*round) / round,
+		}
+		if n := len(out); n > 0 && out[n-1][0] == c[0] && out[n-1][1] == c[1] {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// write stores the sets as gzipped JSON with sorted keys, so regenerating
+// from the same shapefiles gives the same file.
+func write(path string, result map[string]map[string][][][][]float64) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	zw, err := gzip.NewWriterLevel(f, gzip.BestCompression)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := json.NewEncoder(zw).Encode(result); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to encode boundaries: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// record is one polygon shape with its attribute row.
+type record struct {
+	rings [][]point
+	attrs map[string]string
+}
+
+// readZip loads a zipped shapefile from a URL or a local path and returns
+// its polygon records.
+func readZip(src string) ([]record, error) {
+	var data []byte
+	var err error
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		data, err = download(src)
+	} else {
+		data, err = os.ReadFile(src)
+	}
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", src, err)
+	}
+	var shp, dbf []byte
+	for _, f := range zr.File {
+		switch strings.ToLower(path.Ext(f.Name)) {
+		case ".shp":
+			shp, err = readZipFile(f)
+		case ".dbf":
+			dbf, err = readZipFile(f)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", src, err)
+		}
+	}
+	if shp == nil || dbf == nil {
+		return nil, fmt.Errorf("%s: no .shp and .dbf in archive", src)
+	}
+
+	shapes, err := readShapes(shp)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", src, err)
+	}
+	rows, err := readDBF(dbf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", src, err)
+	}
+	if len(rows) != len(shapes) {
+		return nil, fmt.Errorf("%s: %d shapes but %d attribute rows", src, len(shapes), len(rows))
+	}
+	records := make([]record, len(shapes))
+	for i := range shapes {
+		records[i] = record{rings: shapes[i], attrs: rows[i]}
+	}
+	return records, nil
+}
+
+func download(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 5 * time.Minute}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// Shapefile shape types with polygon geometry. The Z and M variants carry
+// extra values after the points, which are skipped.
+const (
+	shapeNull     = 0
+	shapePolygon  = 5
+	shapePolygonZ = 15
+	shapePolygonM = 25
+)
+
+// readShapes parses the main .shp file into each record's rings. Null
+// shapes yield no rings.
+func readShapes(b []byte) ([][][]point, error) {
+	if len(b) < 100 || binary.BigEndian.Uint32(b[0:]) != 9994 {
+		return nil, fmt.Errorf("not a shapefile")
+	}
+	var shapes [][][]point
+	for off := 100; off+8 <= len(b); {
+		size := int(binary.BigEndian.Uint32(b[off+4:])) * 2
+		content := b[off+8:]
+		if size > len(content) {
+			return nil, fmt.Errorf("record at byte %d overruns the file", off)
+		}
+		content = content[:size]
+		off += 8 + size
+
+		if len(content) < 4 {
+			return nil, fmt.Errorf("short record at byte %d", off)
+		}
+		switch binary.LittleEndian.Uint32(content) {
+		case shapeNull:
+			shapes = append(shapes, nil)
+			continue
+		case shapePolygon, shapePolygonZ, shapePolygonM:
+		default:
+			return nil, fmt.Errorf("unsupported shape type %d", binary.LittleEndian.Uint32(content))
+		}
+		if len(content) < 44 {
+			return nil, fmt.Errorf("short polygon record")
+		}
+		numParts := int(binary.LittleEndian.Uint32(content[36:]))
+		numPoints := int(binary.LittleEndian.Uint32(content[40:]))
+		partsAt := 44
+		pointsAt := partsAt + 4*numParts
+		if pointsAt+16*numPoints > len(content) {
+			return nil, fmt.Errorf("polygon record overruns its length")
+		}
+		var rings [][]point
+		for p := 0; p < numParts; p++ {
+			start := int(binary.LittleEndian.Uint32(content[partsAt+4*p:]))
+			end := numPoints
+			if p+1 < numParts {
+				end = int(binary.LittleEndian.Uint32(content[partsAt+4*p+4:]))
+			}
+			if start < 0 || end > numPoints || start >= end {
+				continue
+			}
+			ring := make([]point, 0, end-start)
+			for i := start; i < end; i++ {
+				x := math.Float64frombits(binary.LittleEndian.Uint64(content[pointsAt+16*i:]))
+				y := math.Float64frombits(binary.LittleEndian.Uint64(content[pointsAt+16*i+8:]))
+				ring = append(ring, quantize(x, y))
+			}
+			rings = append(rings, ring)
+		}
+		shapes = append(shapes, rings)
+	}
+	return shapes, nil
+}
+
+// readDBF parses a dBase attribute table into rows of trimmed strings by
+// field name. Deleted rows are kept so rows line up with shapes.
+func readDBF(b []byte) ([]map[string]string, error) {
+	if len(b) < 32 {
+		return nil, fmt.Errorf("short dbf header")
+	}
+	numRecords := int(binary.LittleEndian.Uint32(b[4:]))
+	headerLen := int(binary.LittleEndian.Uint16(b[8:]))
+	recordLen := int(binary.LittleEndian.Uint16(b[10:]))
+
+	type field struct {
+		name   string
+		offset int
+		length int
+	}
+	var fields []field
+	offset := 1 // deletion flag
+	for at := 32; at+32 <= headerLen && b[at] != 0x0D; at += 32 {
+		name := strings.TrimRight(string(b[at:at+11]), "\x00 ")
+		length := int(b[at+16])
+		fields = append(fields, field{strings.ToUpper(name), offset, length})
+		offset += length
+	}
+
+	rows := make([]map[string]string, 0, numRecords)
+	for i := 0; i < numRecords; i++ {
+		start := headerLen + i*recordLen
+		if start+recordLen > len(b) {
+			return nil, fmt.Errorf("dbf record %d overruns the file", i)
+		}
+		rec := b[start : start+recordLen]
+		row := make(map[string]string, len(fields))
+		for _, f := range fields {
+			if f.offset+f.length <= len(rec) {
+				row[f.name] = strings.TrimSpace(string(rec[f.offset : f.offset+f.length]))
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
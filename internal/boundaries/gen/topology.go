@@ -0,0 +1,256 @@
+package main
+
+import "math"
+
+// scale puts source coordinates on a micro-degree grid, so the shared
+// borders of neighbouring shapes compare equal.
+const scale = 1e6
+
+type point struct{ x, y int64 }
+
+func quantize(x, y float64) point {
+	return point{int64(math.Round(x * scale)), int64(math.Round(y * scale))}
+}
+
+func (p point) less(q point) bool {
+	return p.x < q.x || p.x == q.x && p.y < q.y
+}
+
+// arcKey names an arc by its first two points and its last point, taken in
+// the arc's canonical direction.
+type arcKey struct{ a, b, z point }
+
+// simplifier thins the rings of one set of shapes while keeping the set's
+// topology: rings are cut into arcs at the points where three or more
+// borders meet, and each shared arc is simplified once and reused by every
+// ring along it. Neighbouring outlines therefore keep identical borders,
+// with no slivers or overlaps between them.
+type simplifier struct {
+	tolerance  float64
+	neighbours map[point][]point
+	arcs       map[arcKey][]point
+}
+
+func newSimplifier(records []record, tolerance float64) *simplifier {
+	s := &simplifier{
+		tolerance:  tolerance * scale,
+		neighbours: map[point][]point{},
+		arcs:       map[arcKey][]point{},
+	}
+	for _, r := range records {
+		for _, ring := range r.rings {
+			ring = clean(ring)
+			for i := 0; i+1 < len(ring); i++ {
+				s.link(ring[i], ring[i+1])
+				s.link(ring[i+1], ring[i])
+			}
+		}
+	}
+	return s
+}
+
+func (s *simplifier) link(p, q point) {
+	for _, n := range s.neighbours[p] {
+		if n == q {
+			return
+		}
+	}
+	s.neighbours[p] = append(s.neighbours[p], q)
+}
+
+func (s *simplifier) junction(p point) bool {
+	return len(s.neighbours[p]) > 2
+}
+
+// ring returns the simplified ring, or the cleaned original when
+// simplifying would leave fewer than three corners.
+func (s *simplifier) ring(ring []point) []point {
+	ring = clean(ring)
+	if len(ring) < 4 {
+		return nil
+	}
+	open := ring[:len(ring)-1]
+
+	start := -1
+	for i, p := range open {
+		if s.junction(p) {
+			start = i
+			break
+		}
+	}
+	var out []point
+	if start < 0 {
+		// No junctions: an island or an enclave. Start at the lowest point so
+		// every ring tracing the same loop picks the same arc.
+		for i, p := range open {
+			if start < 0 || p.less(open[start]) {
+				start = i
+			}
+		}
+		loop := append(append(append([]point{}, open[start:]...), open[:start]...), open[start])
+		out = s.arc(loop)
+	} else {
+		loop := append(append(append([]point{}, open[start:]...), open[:start]...), open[start])
+		from := 0
+		for i := 1; i < len(loop); i++ {
+			if i == len(loop)-1 || s.junction(loop[i]) {
+				part := s.arc(loop[from : i+1])
+				if len(out) > 0 {
+					part = part[1:]
+				}
+				out = append(out, part...)
+				from = i
+			}
+		}
+	}
+	if out = clean(out); len(out) < 4 {
+		return ring
+	}
+	return out
+}
+
+// arc simplifies one arc, reusing the result for an arc already seen in
+// either direction.
+func (s *simplifier) arc(pts []point) []point {
+	n := len(pts)
+	reversed := n > 1 && (pts[n-1].less(pts[0]) ||
+		pts[n-1] == pts[0] && n > 2 && pts[n-2].less(pts[1]))
+	canon := pts
+	if reversed {
+		canon = reverse(pts)
+	}
+	key := arcKey{canon[0], canon[0], canon[n-1]}
+	if n > 1 {
+		key.b = canon[1]
+	}
+	simple, ok := s.arcs[key]
+	if !ok {
+		simple = douglasPeucker(canon, s.tolerance)
+		s.arcs[key] = simple
+	}
+	if reversed {
+		return reverse(simple)
+	}
+	return append([]point{}, simple...)
+}
+
+// douglasPeucker keeps the end points and every point further than
+// tolerance from the line through its kept neighbours.
+func douglasPeucker(pts []point, tolerance float64) []point {
+	if len(pts) < 3 {
+		return append([]point{}, pts...)
+	}
+	keep := make([]bool, len(pts))
+	keep[0], keep[len(pts)-1] = true, true
+	stack := [][2]int{{0, len(pts) - 1}}
+	for len(stack) > 0 {
+		span := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		far, farDist := -1, tolerance
+		for i := span[0] + 1; i < span[1]; i++ {
+			if d := distance(pts[i], pts[span[0]], pts[span[1]]); d > farDist {
+				far, farDist = i, d
+			}
+		}
+		if far >= 0 {
+			keep[far] = true
+			stack = append(stack, [2]int{span[0], far}, [2]int{far, span[1]})
+		}
+	}
+	out := make([]point, 0, len(pts))
+	for i, k := range keep {
+		if k {
+			out = append(out, pts[i])
+		}
+	}
+	return out
+}
+
+// distance is from p to the segment ab.
+func distance(p, a, b point) float64 {
+	px, py := float64(p.x), float64(p.y)
+	ax, ay := float64(a.x), float64(a.y)
+	dx, dy := float64(b.x)-ax, float64(b.y)-ay
+	if dx == 0 && dy == 0 {
+		return math.Hypot(px-ax, py-ay)
+	}
+	t := math.Max(0, math.Min(1, ((px-ax)*dx+(py-ay)*dy)/(dx*dx+dy*dy)))
+	return math.Hypot(px-ax-t*dx, py-ay-t*dy)
+}
+
+// clean drops repeated points and closes the ring.
+func clean(ring []point) []point {
+	out := make([]point, 0, len(ring)+1)
+	for _, p := range ring {
+		if len(out) == 0 || out[len(out)-1] != p {
+			out = append(out, p)
+		}
+	}
+	if len(out) > 0 && out[0] != out[len(out)-1] {
+		out = append(out, out[0])
+	}
+	return out
+}
+
+func reverse(pts []point) []point {
+	out := make([]point, len(pts))
+	for i, p := range pts {
+		out[len(pts)-1-i] = p
+	}
+	return out
+}
+
+// signedArea is positive for counter-clockwise rings.
+func signedArea(ring []point) float64 {
+	var a float64
+	for i := 0; i+1 < len(ring); i++ {
+		a += float64(ring[i].x)*float64(ring[i+1].y) - float64(ring[i+1].x)*float64(ring[i].y)
+	}
+	return a / 2
+}
+
+// contains is the even-odd ray casting test.
+func contains(ring []point, p point) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.y > p.y) != (b.y > p.y) &&
+			float64(p.x) < float64(b.x-a.x)*float64(p.y-a.y)/float64(b.y-a.y)+float64(a.x) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// polygons groups a shape's rings into GeoJSON polygons. Shapefiles wind
+// outer rings clockwise and holes counter-clockwise; GeoJSON (RFC 7946)
+// wants the opposite, so every ring is reversed.
+func polygons(rings [][]point) [][][]point {
+	var outers, holes [][]point
+	for _, r := range rings {
+		if signedArea(r) < 0 {
+			outers = append(outers, reverse(r))
+		} else {
+			holes = append(holes, reverse(r))
+		}
+	}
+	out := make([][][]point, len(outers))
+	for i, o := range outers {
+		out[i] = [][]point{o}
+	}
+	for _, h := range holes {
+		placed := false
+		for i, o := range outers {
+			if contains(o, h[0]) {
+				out[i] = append(out[i], h)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			// A hole outside every outer ring is a mis-wound outer ring.
+			out = append(out, [][]point{reverse(h)})
+		}
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+// border is the wiggly line two neighbouring counties share, with detail
+// well under the simplification tolerance.
+var border = [][2]float64{{2, 0}, {2.001, 0.5}, {1.999, 1}, {2.001, 1.5}, {2, 2}}
+
+func ring(coords ...[2]float64) []point {
+	out := make([]point, len(coords))
+	for i, c := range coords {
+		out[i] = quantize(c[0], c[1])
+	}
+	return out
+}
+
+func TestSharedBorderSimplifiesIdentically(t *testing.T) {
+	var westCoords, eastCoords [][2]float64
+	westCoords = append(westCoords, [2]float64{0, 0})
+	westCoords = append(westCoords, border...)
+	westCoords = append(westCoords, [2]float64{0, 2}, [2]float64{0, 0})
+	eastCoords = append(eastCoords, [2]float64{2, 0}, [2]float64{4, 0}, [2]float64{4, 2})
+	for i := len(border) - 1; i >= 0; i-- {
+		eastCoords = append(eastCoords, border[i])
+	}
+	west, east := ring(westCoords...), ring(eastCoords...)
+
+	s := newSimplifier([]record{{rings: [][]point{west}}, {rings: [][]point{east}}}, 0.005)
+	sw, se := s.ring(west), s.ring(east)
+	if len(sw) >= len(west) || len(se) >= len(east) {
+		t.Fatalf("border detail was not simplified: %v, %v", sw, se)
+	}
+
+	// The shared border must cancel exactly, leaving one closed loop around
+	// both counties.
+	type edge struct{ a, b point }
+	edges := map[edge]int{}
+	for _, r := range [][]point{sw, se} {
+		for i := 0; i+1 < len(r); i++ {
+			if edges[edge{r[i+1], r[i]}] > 0 {
+				edges[edge{r[i+1], r[i]}]--
+				continue
+			}
+			edges[edge{r[i], r[i+1]}]++
+		}
+	}
+	degree := map[point]int{}
+	remaining := 0
+	for e, n := range edges {
+		degree[e.a] += n
+		degree[e.b] += n
+		remaining += n
+	}
+	for p, d := range degree {
+		if d != 0 && d != 2 {
+			t.Errorf("point %v has %d outline edges, want 2", p, d)
+		}
+	}
+	if remaining != 6 {
+		t.Errorf("%d edges left after cancelling the shared border, want the 6 around both counties", remaining)
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/coverage"
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+	"github.com/Zachdehooge/warnings-dashboard/internal/generator"
+	"github.com/Zachdehooge/warnings-dashboard/internal/notify"
+	"github.com/Zachdehooge/warnings-dashboard/internal/tiles"
+	"github.com/Zachdehooge/warnings-dashboard/internal/web"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the dashboard's YAML configuration file. Command-line flags
+// override anything set here.
+type Config struct {
+	// Rules is the path of an alert filter rules file.
+	Rules string `yaml:"rules"`
+	// History is the path of the alert history database; history is not
+	// kept when empty.
+	History string `yaml:"history"`
+	// Listen is the watch-mode HTTP server address, or "off" to write
+	// warnings.json to disk for an external server instead.
+	Listen string `yaml:"listen"`
+	// Locations are watched sites shown in the page's "My locations" panel.
+	Locations []coverage.Location  `yaml:"locations"`
+	Notify    notify.Config        `yaml:"notify"`
+	NWS       fetcher.NWSOptions   `yaml:"nws"`
+	SPC       generator.MCDOptions `yaml:"spc"`
+	// Web chooses where the page's templates, scripts, Leaflet and basemap
+	// come from.
+	Web web.Options `yaml:"web"`
+	// Tiles configures the server's basemap and radar tile proxy.
+	Tiles tiles.Options `yaml:"tiles"`
+}
+
+// Load reads the configuration file at path. An empty path returns an empty
+// configuration, which leaves every setting at its built-in default.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return cfg, nil
+}
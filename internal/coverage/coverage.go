@@ -0,0 +1,254 @@
+package coverage
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+	"github.com/Zachdehooge/warnings-dashboard/internal/geo"
+)
+
+// How an alert was found to cover a location.
+const (
+	ByPolygon = "polygon"
+	ByUGC     = "ugc"
+	BySAME    = "same"
+	ByOutline = "outline"
+)
+
+// Location is a place to test against alerts. UGC and SAME codes are only
+// consulted for alerts without a polygon.
+type Location struct {
+	Name string   `yaml:"name" json:"name,omitempty"`
+	Lat  float64  `yaml:"lat" json:"lat"`
+	Lon  float64  `yaml:"lon" json:"lon"`
+	UGC  []string `yaml:"ugc" json:"ugc,omitempty"`
+	SAME []string `yaml:"same" json:"same,omitempty"`
+	// FIPS is the five-digit county FIPS code; NormalizeCodes adds it to SAME.
+	FIPS string `yaml:"fips" json:"fips,omitempty"`
+}
+
+// Match is an alert covering a location and how it was matched.
+type Match struct {
+	Alert fetcher.Warning `json:"alert"`
+	By    string          `json:"by"`
+}
+
+// Covers reports whether an alert covers loc. An issued polygon is
+// authoritative: storm-based warnings list every county they touch, so
+// falling back to codes would over-warn. Alerts without one, such as most
+// watches, match on UGC and then SAME codes. Their derived zone outline is
+// only used when the location has no codes, since the simplified borders are
+// less exact than the codes themselves.
+func Covers(w fetcher.Warning, loc Location) (string, bool) {
+	if w.Geometry != nil && !w.GeometryDerived {
+		if geo.ContainsPoint(w.Geometry.Type, w.Geometry.Coordinates, loc.Lon, loc.Lat) {
+			return ByPolygon, true
+		}
+		return "", false
+	}
+	if anyCode(w.UGC, loc.UGC) {
+		return ByUGC, true
+	}
+	if anyCode(w.SAME, loc.SAME) {
+		return BySAME, true
+	}
+	if w.Geometry != nil && len(loc.UGC) == 0 && len(loc.SAME) == 0 &&
+		geo.ContainsPoint(w.Geometry.Type, w.Geometry.Coordinates, loc.Lon, loc.Lat) {
+		return ByOutline, true
+	}
+	return "", false
+}
+
+// Result answers "is this location under an alert?".
+type Result struct {
+	Location Location `json:"location"`
+	// Covered is set when any alert covers the location, Warned only when
+	// one of them is a warning.
+	Covered bool    `json:"covered"`
+	Warned  bool    `json:"warned"`
+	Matches []Match `json:"matches"`
+}
+
+// CheckLocation checks loc against ws and summarises the result.
+func CheckLocation(ws []fetcher.Warning, loc Location) Result {
+	matches := Check(ws, loc)
+	return Result{Location: loc, Covered: len(matches) > 0, Warned: Warned(matches), Matches: matches}
+}
+
+// Check returns the alerts covering loc, in the order given.
+func Check(ws []fetcher.Warning, loc Location) []Match {
+	matches := []Match{}
+	for _, w := range ws {
+		if by, ok := Covers(w, loc); ok {
+			matches = append(matches, Match{Alert: w, By: by})
+		}
+	}
+	return matches
+}
+
+// Warned reports whether any match is a warning rather than a watch,
+// advisory or statement.
+func Warned(matches []Match) bool {
+	for _, m := range matches {
+		if strings.HasSuffix(m.Alert.Type, "Warning") {
+			return true
+		}
+	}
+	return false
+}
+
+func anyCode(alert, loc []string) bool {
+	for _, a := range alert {
+		for _, l := range loc {
+			if strings.EqualFold(a, l) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Resolver limits: the most recently used points it remembers, and the
+// rate of NWS lookups, a burst of lookupBurst refilled at lookupRate a
+// second. A lookup waits up to lookupWait for its turn and is refused
+// after that. Points come from a public API endpoint, so neither may grow
+// with what clients send.
+const (
+	maxResolved = 1024
+	lookupBurst = 10
+	lookupRate  = 1.0
+	lookupWait  = 5 * time.Second
+)
+
+// ErrLookupLimit is returned by Resolve when lookups are arriving faster
+// than the NWS API should be asked.
+var ErrLookupLimit = errors.New("too many zone lookups, try again shortly")
+
+// Resolver fills in the UGC and SAME codes of locations from the NWS points
+// endpoint, remembering recent answers since zone assignments rarely change.
+type Resolver struct {
+	NWS fetcher.NWSOptions
+
+	mu      sync.Mutex
+	cache   map[string]*list.Element
+	lru     *list.List // of *resolved, most recently used first
+	tokens  float64
+	refresh time.Time
+}
+
+type resolved struct {
+	key   string
+	codes []string
+}
+
+// NewResolver returns a resolver that queries the NWS API described by opts.
+func NewResolver(opts fetcher.NWSOptions) *Resolver {
+	return &Resolver{
+		NWS:    opts,
+		cache:  make(map[string]*list.Element),
+		lru:    list.New(),
+		tokens: lookupBurst,
+	}
+}
+
+// lookup returns the cached codes for key, or reserves a lookup and how
+// long to wait before making it. The caller holds r.mu.
+func (r *Resolver) lookup(key string) ([]string, bool, time.Duration, error) {
+	if el, ok := r.cache[key]; ok {
+		r.lru.MoveToFront(el)
+		return el.Value.(*resolved).codes, true, 0, nil
+	}
+	now := time.Now()
+	if !r.refresh.IsZero() {
+		r.tokens = math.Min(lookupBurst, r.tokens+now.Sub(r.refresh).Seconds()*lookupRate)
+	}
+	r.refresh = now
+	wait := time.Duration((1 - r.tokens) / lookupRate * float64(time.Second))
+	if wait > lookupWait {
+		return nil, false, 0, ErrLookupLimit
+	}
+	r.tokens--
+	return nil, false, max(wait, 0), nil
+}
+
+// remember caches the codes for key, dropping the least recently used
+// point beyond maxResolved. The caller holds r.mu.
+func (r *Resolver) remember(key string, codes []string) {
+	if _, ok := r.cache[key]; ok {
+		return
+	}
+	r.cache[key] = r.lru.PushFront(&resolved{key, codes})
+	if r.lru.Len() > maxResolved {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.cache, oldest.Value.(*resolved).key)
+	}
+}
+
+// Resolve adds the point's zones to loc.UGC and the SAME codes of its
+// counties to loc.SAME. Codes already set are kept. On error, including
+// ErrLookupLimit, loc is left unchanged and can still be checked against
+// alert polygons.
+func (r *Resolver) Resolve(loc *Location) error {
+	key := fmt.Sprintf("%.4f,%.4f", loc.Lat, loc.Lon)
+	r.mu.Lock()
+	codes, ok, wait, err := r.lookup(key)
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		time.Sleep(wait)
+		if codes, err = r.NWS.PointZones(loc.Lat, loc.Lon); err != nil {
+			return err
+		}
+		r.mu.Lock()
+		r.remember(key, codes)
+		r.mu.Unlock()
+	}
+
+	for _, c := range codes {
+		loc.UGC = appendUnique(loc.UGC, c)
+		if same, ok := geo.CountySAME(c); ok {
+			loc.SAME = appendUnique(loc.SAME, same)
+		}
+	}
+	return nil
+}
+
+// NormalizeCodes upper-cases UGC codes and converts county FIPS codes,
+// including FIPS, to SAME, dropping any that are malformed or repeated. It
+// builds new slices, so the caller's are left as they were, and calling it
+// again changes nothing.
+func (loc *Location) NormalizeCodes() {
+	var ugc []string
+	for _, c := range loc.UGC {
+		if c = strings.ToUpper(strings.TrimSpace(c)); c != "" {
+			ugc = appendUnique(ugc, c)
+		}
+	}
+	loc.UGC = ugc
+
+	var same []string
+	for _, c := range append(loc.SAME[:len(loc.SAME):len(loc.SAME)], loc.FIPS) {
+		if s, ok := geo.NormalizeSAME(c); ok {
+			same = appendUnique(same, s)
+		}
+	}
+	loc.SAME = same
+}
+
+func appendUnique(list []string, v string) []string {
+	for _, x := range list {
+		if strings.EqualFold(x, v) {
+			return list
+		}
+	}
+	return append(list, v)
+}
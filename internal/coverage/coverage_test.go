@@ -0,0 +1,74 @@
+package coverage
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+)
+
+func TestResolverForgetsLeastRecentlyUsed(t *testing.T) {
+	r := NewResolver(fetcher.NWSOptions{})
+	for i := range maxResolved + 1 {
+		r.remember(fmt.Sprint(i), []string{"ALZ001"})
+		if i == 0 {
+			continue
+		}
+		// Keep the first point in use.
+		if _, ok, _, _ := r.lookup("0"); !ok {
+			t.Fatalf("point 0 forgotten after %d others", i)
+		}
+	}
+	if len(r.cache) != maxResolved || r.lru.Len() != maxResolved {
+		t.Fatalf("cache holds %d points, want %d", len(r.cache), maxResolved)
+	}
+	if _, ok := r.cache["1"]; ok {
+		t.Error("least recently used point still cached")
+	}
+}
+
+func TestResolverRefusesLookupFloods(t *testing.T) {
+	r := NewResolver(fetcher.NWSOptions{})
+	var refused error
+	for i := 0; refused == nil; i++ {
+		if i > lookupBurst+int(lookupWait.Seconds()*lookupRate)+1 {
+			t.Fatal("lookups never refused")
+		}
+		_, _, wait, err := r.lookup(fmt.Sprint(i))
+		if i < lookupBurst && wait != 0 {
+			t.Errorf("lookup %d within the burst waits %v", i, wait)
+		}
+		if wait > lookupWait {
+			t.Errorf("lookup %d waits %v, longer than %v", i, wait, lookupWait)
+		}
+		refused = err
+	}
+	if !errors.Is(refused, ErrLookupLimit) {
+		t.Fatalf("got %v, want ErrLookupLimit", refused)
+	}
+}
+
+func TestNormalizeCodes(t *testing.T) {
+	ugc := []string{" alc073", "ALZ024", "", "ALC073"}
+	same := make([]string, 2, 4)
+	copy(same, []string{"01073", "bad"})
+	loc := Location{UGC: ugc[:3], SAME: same, FIPS: "01117"}
+
+	loc.NormalizeCodes()
+	loc.NormalizeCodes()
+	if want := []string{"ALC073", "ALZ024"}; !reflect.DeepEqual(loc.UGC, want) {
+		t.Errorf("UGC = %q, want %q", loc.UGC, want)
+	}
+	if want := []string{"001073", "001117"}; !reflect.DeepEqual(loc.SAME, want) {
+		t.Errorf("SAME = %q, want %q", loc.SAME, want)
+	}
+	// The caller's slices, including the spare capacity, are untouched.
+	if want := []string{" alc073", "ALZ024", "", "ALC073"}; !reflect.DeepEqual(ugc, want) {
+		t.Errorf("caller's UGC changed to %q", ugc)
+	}
+	if got := same[:4]; !reflect.DeepEqual(got, []string{"01073", "bad", "", ""}) {
+		t.Errorf("caller's SAME changed to %q", got)
+	}
+}
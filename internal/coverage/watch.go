@@ -0,0 +1,162 @@
+package coverage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+)
+
+// Kinds of location event.
+const (
+	Enter = "enter"
+	Leave = "leave"
+)
+
+// eventRetention is how long location events stay in the payload.
+const eventRetention = 10 * time.Minute
+
+// Status is where a watched location stands in the current cycle.
+type Status struct {
+	Name    string        `json:"name"`
+	Lat     float64       `json:"lat"`
+	Lon     float64       `json:"lon"`
+	Covered bool          `json:"covered"`
+	Warned  bool          `json:"warned"`
+	Alerts  []StatusAlert `json:"alerts"`
+}
+
+// StatusAlert is an alert covering a watched location.
+type StatusAlert struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	Severity    string `json:"severity"`
+	Tier        string `json:"tier"`
+	ExpiresTime string `json:"expiresTime"`
+	By          string `json:"by"`
+}
+
+// Event records a watched location coming under, or out from under, an
+// alert.
+type Event struct {
+	Kind     string `json:"kind"`
+	Location string `json:"location"`
+	AlertID  string `json:"alertId"`
+	Type     string `json:"type"`
+	Severity string `json:"severity"`
+	Tier     string `json:"tier"`
+	At       string `json:"at"`
+}
+
+// Watcher evaluates a fixed set of locations every poll cycle and reports
+// when each enters or leaves an alert. It is not safe for concurrent use.
+type Watcher struct {
+	Locations []Location
+
+	prev   map[string]map[string]StatusAlert
+	events []Event
+}
+
+// NewWatcher returns a watcher for locs. Locations without a name are named
+// after their coordinates. The first Evaluate establishes the baseline and
+// reports no events.
+func NewWatcher(locs []Location) *Watcher {
+	named := make([]Location, len(locs))
+	for i, l := range locs {
+		if l.Name == "" {
+			l.Name = fmt.Sprintf("%.4f,%.4f", l.Lat, l.Lon)
+		}
+		named[i] = l
+	}
+	return &Watcher{Locations: named}
+}
+
+// Evaluate checks every location against the cycle's alerts. It returns the
+// status of each location and the events of the last few minutes, newest
+// first. An alert that replaces one already covering a location, such as an
+// update or extension, continues it rather than leaving and re-entering.
+func (w *Watcher) Evaluate(ws []fetcher.Warning, now time.Time) ([]Status, []Event) {
+	at := now.UTC().Format(time.RFC3339)
+	baseline := w.prev == nil
+	statuses := make([]Status, 0, len(w.Locations))
+	current := make(map[string]map[string]StatusAlert, len(w.Locations))
+	var fresh []Event
+
+	for _, loc := range w.Locations {
+		res := CheckLocation(ws, loc)
+		st := Status{Name: loc.Name, Lat: loc.Lat, Lon: loc.Lon, Covered: res.Covered, Warned: res.Warned, Alerts: []StatusAlert{}}
+		cur := make(map[string]StatusAlert, len(res.Matches))
+		prev := w.prev[loc.Name]
+		continued := map[string]bool{}
+
+		for _, m := range res.Matches {
+			sa := StatusAlert{
+				ID:          m.Alert.ID,
+				Type:        m.Alert.Type,
+				Severity:    m.Alert.Severity,
+				Tier:        m.Alert.Tier,
+				ExpiresTime: m.Alert.ExpiresTime,
+				By:          m.By,
+			}
+			st.Alerts = append(st.Alerts, sa)
+			cur[sa.ID] = sa
+			if baseline {
+				continue
+			}
+			if _, ok := prev[sa.ID]; ok {
+				continue
+			}
+			if ref, ok := replaces(m.Alert, prev); ok {
+				continued[ref] = true
+				continue
+			}
+			fresh = append(fresh, newEvent(Enter, loc.Name, sa, at))
+		}
+		for id, sa := range prev {
+			if _, ok := cur[id]; !ok && !continued[id] {
+				fresh = append(fresh, newEvent(Leave, loc.Name, sa, at))
+			}
+		}
+
+		current[loc.Name] = cur
+		statuses = append(statuses, st)
+	}
+	w.prev = current
+
+	w.events = append(fresh, w.events...)
+	cutoff := now.Add(-eventRetention)
+	kept := w.events[:0]
+	for _, e := range w.events {
+		if ts, err := time.Parse(time.RFC3339, e.At); err == nil && ts.Before(cutoff) {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	w.events = kept
+
+	out := make([]Event, len(w.events))
+	copy(out, w.events)
+	return statuses, out
+}
+
+// replaces returns the ID of the alert in prev that a references.
+func replaces(a fetcher.Warning, prev map[string]StatusAlert) (string, bool) {
+	for _, r := range a.References {
+		if _, ok := prev[r.Identifier]; ok {
+			return r.Identifier, true
+		}
+	}
+	return "", false
+}
+
+func newEvent(kind, location string, sa StatusAlert, at string) Event {
+	return Event{
+		Kind:     kind,
+		Location: location,
+		AlertID:  sa.ID,
+		Type:     sa.Type,
+		Severity: sa.Severity,
+		Tier:     sa.Tier,
+		At:       at,
+	}
+}
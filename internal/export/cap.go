@@ -0,0 +1,166 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+	"github.com/Zachdehooge/warnings-dashboard/internal/geo"
+)
+
+// capAlert is a CAP 1.2 alert message with one info block.
+type capAlert struct {
+	XMLName    xml.Name `xml:"urn:oasis:names:tc:emergency:cap:1.2 alert"`
+	Identifier string   `xml:"identifier"`
+	Sender     string   `xml:"sender"`
+	Sent       string   `xml:"sent"`
+	Status     string   `xml:"status"`
+	MsgType    string   `xml:"msgType"`
+	Scope      string   `xml:"scope"`
+	References string   `xml:"references,omitempty"`
+	Info       capInfo  `xml:"info"`
+}
+
+type capInfo struct {
+	Language    string         `xml:"language"`
+	Category    string         `xml:"category"`
+	Event       string         `xml:"event"`
+	Urgency     string         `xml:"urgency"`
+	Severity    string         `xml:"severity"`
+	Certainty   string         `xml:"certainty"`
+	Onset       string         `xml:"onset,omitempty"`
+	Expires     string         `xml:"expires,omitempty"`
+	SenderName  string         `xml:"senderName,omitempty"`
+	Headline    string         `xml:"headline,omitempty"`
+	Description string         `xml:"description,omitempty"`
+	Instruction string         `xml:"instruction,omitempty"`
+	Parameters  []capValuePair `xml:"parameter"`
+	Area        capArea        `xml:"area"`
+}
+
+type capValuePair struct {
+	ValueName string `xml:"valueName"`
+	Value     string `xml:"value"`
+}
+
+type capArea struct {
+	AreaDesc string         `xml:"areaDesc"`
+	Polygons []string       `xml:"polygon"`
+	Geocodes []capValuePair `xml:"geocode"`
+}
+
+// capAlerts wraps several alerts in one document. CAP itself has no
+// collection element; each child is a complete CAP 1.2 alert.
+type capAlerts struct {
+	XMLName xml.Name   `xml:"alerts"`
+	Alerts  []capAlert `xml:"alert"`
+}
+
+// EncodeCAP writes one alert as a CAP 1.2 document.
+func EncodeCAP(w io.Writer, a fetcher.Warning) error {
+	return writeXML(w, toCAP(a))
+}
+
+func encodeCAPList(w io.Writer, warnings []fetcher.Warning) error {
+	doc := capAlerts{Alerts: []capAlert{}}
+	for _, a := range warnings {
+		doc.Alerts = append(doc.Alerts, toCAP(a))
+	}
+	return writeXML(w, doc)
+}
+
+func toCAP(a fetcher.Warning) capAlert {
+	c := capAlert{
+		Identifier: a.ID,
+		Sender:     nwsSender,
+		Sent:       a.Time,
+		Status:     orDefault(a.Status, "Actual"),
+		MsgType:    orDefault(a.MessageType, "Alert"),
+		Scope:      "Public",
+		Info: capInfo{
+			Language:    "en-US",
+			Category:    "Met",
+			Event:       a.Type,
+			Urgency:     orDefault(a.Urgency, "Unknown"),
+			Severity:    orDefault(a.Severity, "Unknown"),
+			Certainty:   orDefault(a.Certainty, "Unknown"),
+			Onset:       a.Onset,
+			Expires:     a.ExpiresTime,
+			SenderName:  a.SenderName,
+			Headline:    a.Headline,
+			Description: a.Description,
+			Instruction: a.Instruction,
+			Area:        capArea{AreaDesc: a.Area},
+		},
+	}
+
+	// references is a space-separated list of sender,identifier,sent.
+	var refs []string
+	for _, r := range a.References {
+		if r.Identifier != "" {
+			refs = append(refs, orDefault(r.Sender, nwsSender)+","+r.Identifier+","+r.Sent)
+		}
+	}
+	c.References = strings.Join(refs, " ")
+
+	names := make([]string, 0, len(a.Parameters))
+	for name := range a.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range a.Parameters[name] {
+			c.Info.Parameters = append(c.Info.Parameters, capValuePair{name, v})
+		}
+	}
+
+	// A derived outline is not part of the alert as issued.
+	if a.Geometry != nil && !a.GeometryDerived {
+		for _, rings := range geo.Polygons(a.Geometry.Type, a.Geometry.Coordinates) {
+			if len(rings) > 0 {
+				c.Info.Area.Polygons = append(c.Info.Area.Polygons, capPolygon(rings[0]))
+			}
+		}
+	}
+	for _, code := range a.SAME {
+		c.Info.Area.Geocodes = append(c.Info.Area.Geocodes, capValuePair{"SAME", code})
+	}
+	for _, code := range a.UGC {
+		c.Info.Area.Geocodes = append(c.Info.Area.Geocodes, capValuePair{"UGC", code})
+	}
+	return c
+}
+
+// capPolygon formats a ring as CAP's space-separated "lat,lon" pairs.
+func capPolygon(ring [][]float64) string {
+	ps := make([]string, 0, len(ring))
+	for _, p := range ring {
+		if len(p) >= 2 {
+			ps = append(ps, fmt.Sprintf("%g,%g", p[1], p[0]))
+		}
+	}
+	return strings.Join(ps, " ")
+}
+
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,76 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+	"github.com/Zachdehooge/warnings-dashboard/internal/geo"
+)
+
+var csvHeader = []string{
+	"id", "event", "tier", "severity", "certainty", "urgency", "status", "messageType",
+	"area", "sent", "onset", "ends", "expires", "senderName", "headline",
+	"ugc", "same", "maxHailSize", "maxWindGust", "tornadoDetection",
+	"tornadoDamageThreat", "thunderstormDamageThreat", "wkt",
+}
+
+// encodeCSV writes one row per alert. Code lists are space separated and
+// the polygon is WKT, which GIS tools import as the row's geometry.
+func encodeCSV(w io.Writer, warnings []fetcher.Warning) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, a := range warnings {
+		hail, gust := "", ""
+		if a.MaxHailSize > 0 {
+			hail = strconv.FormatFloat(a.MaxHailSize, 'f', -1, 64)
+		}
+		if a.MaxWindGust > 0 {
+			gust = strconv.Itoa(a.MaxWindGust)
+		}
+		if err := cw.Write([]string{
+			a.ID, a.Type, a.Tier, a.Severity, a.Certainty, a.Urgency, a.Status, a.MessageType,
+			a.Area, a.Time, a.Onset, a.Ends, a.ExpiresTime, a.SenderName, a.Headline,
+			strings.Join(a.UGC, " "), strings.Join(a.SAME, " "), hail, gust, a.TornadoDetection,
+			a.TornadoDamageThreat, a.ThunderstormDamageThreat, wkt(a.Geometry),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// wkt renders a polygon geometry as WKT, or "" when there is none.
+func wkt(g *fetcher.Geometry) string {
+	if g == nil {
+		return ""
+	}
+	polygons := geo.Polygons(g.Type, g.Coordinates)
+	if len(polygons) == 0 {
+		return ""
+	}
+	parts := make([]string, len(polygons))
+	for i, rings := range polygons {
+		rs := make([]string, len(rings))
+		for j, ring := range rings {
+			ps := make([]string, 0, len(ring))
+			for _, p := range ring {
+				if len(p) >= 2 {
+					ps = append(ps, fmt.Sprintf("%g %g", p[0], p[1]))
+				}
+			}
+			rs[j] = "(" + strings.Join(ps, ", ") + ")"
+		}
+		parts[i] = "(" + strings.Join(rs, ", ") + ")"
+	}
+	if len(parts) == 1 {
+		return "POLYGON " + parts[0]
+	}
+	return "MULTIPOLYGON (" + strings.Join(parts, ", ") + ")"
+}
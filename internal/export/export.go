@@ -0,0 +1,158 @@
+// Package export encodes alerts in standard formats for other tools: GeoJSON
+// for GIS, CAP 1.2 XML, CSV, KML for Google Earth and RSS or Atom feeds.
+// Every format is derived from the same fetcher.Warning model the dashboard
+// uses.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+)
+
+// Formats that Encode accepts.
+const (
+	GeoJSON = "geojson"
+	CAP     = "cap"
+	CSV     = "csv"
+	KML     = "kml"
+	RSS     = "rss"
+	Atom    = "atom"
+)
+
+// Formats lists every format, in the order they are documented.
+var Formats = []string{GeoJSON, CAP, CSV, KML, RSS, Atom}
+
+// nwsSender is the CAP sender of NWS alerts, which the alert model does not
+// carry.
+const nwsSender = "w-nws.webmaster@noaa.gov"
+
+// Options describe the collection being encoded.
+type Options struct {
+	// Title names the collection in KML documents and feeds.
+	Title string
+	// Link is the URL of the dashboard; feed entries link to it.
+	Link string
+	// Updated is when the alerts were read; zero means now.
+	Updated time.Time
+}
+
+func (o Options) withDefaults() Options {
+	if o.Title == "" {
+		o.Title = "US Weather Warnings"
+	}
+	if o.Updated.IsZero() {
+		o.Updated = time.Now()
+	}
+	return o
+}
+
+// Valid reports whether format is one Encode accepts.
+func Valid(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFormats splits and validates a list of formats.
+func ParseFormats(list []string) ([]string, error) {
+	var out []string
+	for _, f := range list {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" {
+			continue
+		}
+		if !Valid(f) {
+			return nil, fmt.Errorf("unknown format %q (want one of %s)", f, strings.Join(Formats, ", "))
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// Extension returns the file extension used for format.
+func Extension(format string) string {
+	if format == RSS || format == Atom {
+		return "." + format + ".xml"
+	}
+	if format == CAP {
+		return ".cap.xml"
+	}
+	return "." + format
+}
+
+// ContentType returns the media type of format.
+func ContentType(format string) string {
+	switch format {
+	case GeoJSON:
+		return "application/geo+json"
+	case CAP:
+		return "application/cap+xml"
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case KML:
+		return "application/vnd.google-earth.kml+xml"
+	case RSS:
+		return "application/rss+xml"
+	case Atom:
+		return "application/atom+xml"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Encode writes warnings to w in format.
+func Encode(w io.Writer, format string, warnings []fetcher.Warning, opts Options) error {
+	opts = opts.withDefaults()
+	switch format {
+	case GeoJSON:
+		return encodeGeoJSON(w, warnings)
+	case CAP:
+		return encodeCAPList(w, warnings)
+	case CSV:
+		return encodeCSV(w, warnings)
+	case KML:
+		return encodeKML(w, warnings, opts)
+	case RSS:
+		return encodeRSS(w, warnings, opts)
+	case Atom:
+		return encodeAtom(w, warnings, opts)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// color is the dashboard's map colour for an alert, as RGB hex without #.
+func color(w fetcher.Warning) string {
+	switch w.Tier {
+	case fetcher.TierEmergency:
+		return "FF00FF"
+	case fetcher.TierPDS:
+		return "FF6A00"
+	}
+	t := strings.ToLower(w.Type)
+	switch {
+	case strings.Contains(t, "tornado warning"):
+		return "FF1493"
+	case strings.Contains(t, "tornado") && strings.Contains(t, "watch"):
+		return "FFFF00"
+	case strings.Contains(t, "thunderstorm warning"):
+		return "FF0000"
+	case strings.Contains(t, "thunderstorm") && strings.Contains(t, "watch"):
+		return "FFA500"
+	case strings.Contains(t, "special weather statement"):
+		return "66B2FF"
+	case w.Severity == "Severe" || w.Severity == "Extreme":
+		return "FF4444"
+	case w.Severity == "Moderate":
+		return "FFAA00"
+	default:
+		return "666666"
+	}
+}
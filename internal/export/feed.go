@@ -0,0 +1,141 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+)
+
+// defaultLink is the feed link when no dashboard URL is known.
+const defaultLink = "https://www.weather.gov/"
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+	Category    string  `xml:"category"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID       string       `xml:"id"`
+	Title    string       `xml:"title"`
+	Updated  string       `xml:"updated"`
+	Link     atomLink     `xml:"link"`
+	Summary  string       `xml:"summary"`
+	Author   *atomAuthor  `xml:"author,omitempty"`
+	Category atomCategory `xml:"category"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// encodeRSS writes an RSS 2.0 feed with one item per alert, titled the way
+// the dashboard lists it, for tickers and feed readers.
+func encodeRSS(w io.Writer, warnings []fetcher.Warning, opts Options) error {
+	link := orDefault(opts.Link, defaultLink)
+	ch := rssChannel{
+		Title:         opts.Title,
+		Link:          link,
+		Description:   "Active weather alerts",
+		LastBuildDate: opts.Updated.Format(time.RFC1123Z),
+	}
+	for _, a := range warnings {
+		item := rssItem{
+			Title:       feedTitle(a),
+			Link:        link,
+			Description: feedSummary(a),
+			GUID:        rssGUID{Value: a.ID},
+			Category:    a.Type,
+		}
+		if t, err := time.Parse(time.RFC3339, a.Time); err == nil {
+			item.PubDate = t.Format(time.RFC1123Z)
+		}
+		ch.Items = append(ch.Items, item)
+	}
+	return writeXML(w, rss{Version: "2.0", Channel: ch})
+}
+
+// encodeAtom writes an Atom 1.0 feed with one entry per alert. Entry IDs
+// are the alerts' NWS IDs, so readers see each alert once.
+func encodeAtom(w io.Writer, warnings []fetcher.Warning, opts Options) error {
+	link := orDefault(opts.Link, defaultLink)
+	updated := opts.Updated.UTC().Format(time.RFC3339)
+	feed := atomFeed{
+		ID:      link,
+		Title:   opts.Title,
+		Updated: updated,
+		Link:    atomLink{Href: link},
+		Author:  atomAuthor{Name: "warnings-dashboard"},
+	}
+	for _, a := range warnings {
+		e := atomEntry{
+			ID:       a.ID,
+			Title:    feedTitle(a),
+			Updated:  updated,
+			Link:     atomLink{Href: link},
+			Summary:  feedSummary(a),
+			Category: atomCategory{Term: a.Type},
+		}
+		if t, err := time.Parse(time.RFC3339, a.Time); err == nil {
+			e.Updated = t.UTC().Format(time.RFC3339)
+		}
+		if a.SenderName != "" {
+			e.Author = &atomAuthor{Name: a.SenderName}
+		}
+		feed.Entries = append(feed.Entries, e)
+	}
+	return writeXML(w, feed)
+}
+
+func feedTitle(a fetcher.Warning) string {
+	return a.DisplayType() + " for " + a.Area
+}
+
+func feedSummary(a fetcher.Warning) string {
+	if a.Headline != "" {
+		return a.Headline
+	}
+	return a.Description
+}
@@ -0,0 +1,45 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+)
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+// feature is an RFC 7946 Feature. Alerts issued without a polygon, such as
+// most watches, carry the outline of their zones with geometryDerived set,
+// or a null geometry when the outline is unknown.
+type feature struct {
+	Type       string            `json:"type"`
+	ID         string            `json:"id"`
+	Geometry   *fetcher.Geometry `json:"geometry"`
+	Properties properties        `json:"properties"`
+}
+
+// properties is the alert without its geometry, which the feature carries.
+// The shallower Geometry field hides the embedded one from encoding/json.
+type properties struct {
+	fetcher.Warning
+	Geometry *struct{} `json:"geometry,omitempty"`
+}
+
+func encodeGeoJSON(w io.Writer, warnings []fetcher.Warning) error {
+	fc := featureCollection{Type: "FeatureCollection", Features: []feature{}}
+	for _, a := range warnings {
+		fc.Features = append(fc.Features, feature{
+			Type:       "Feature",
+			ID:         a.ID,
+			Geometry:   a.Geometry,
+			Properties: properties{Warning: a},
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(fc)
+}
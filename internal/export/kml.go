@@ -0,0 +1,139 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+	"github.com/Zachdehooge/warnings-dashboard/internal/geo"
+)
+
+type kmlRoot struct {
+	XMLName  xml.Name    `xml:"http://www.opengis.net/kml/2.2 kml"`
+	Document kmlDocument `xml:"Document"`
+}
+
+type kmlDocument struct {
+	Name       string         `xml:"name"`
+	Styles     []kmlStyle     `xml:"Style"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlStyle struct {
+	ID        string `xml:"id,attr"`
+	LineColor string `xml:"LineStyle>color"`
+	LineWidth int    `xml:"LineStyle>width"`
+	PolyColor string `xml:"PolyStyle>color"`
+}
+
+type kmlPlacemark struct {
+	Name        string            `xml:"name"`
+	Description string            `xml:"description"`
+	TimeSpan    *kmlTimeSpan      `xml:"TimeSpan,omitempty"`
+	StyleURL    string            `xml:"styleUrl"`
+	Geometry    *kmlMultiGeometry `xml:"MultiGeometry,omitempty"`
+}
+
+type kmlTimeSpan struct {
+	Begin string `xml:"begin,omitempty"`
+	End   string `xml:"end,omitempty"`
+}
+
+type kmlMultiGeometry struct {
+	Polygons []kmlPolygon `xml:"Polygon"`
+}
+
+type kmlPolygon struct {
+	Outer kmlBoundary   `xml:"outerBoundaryIs"`
+	Inner []kmlBoundary `xml:"innerBoundaryIs"`
+}
+
+type kmlBoundary struct {
+	Coordinates string `xml:"LinearRing>coordinates"`
+}
+
+// encodeKML writes a KML document with one placemark per alert. Polygons
+// are outlined and shaded in the dashboard's colour for the alert; alerts
+// without a polygon are listed without geometry.
+func encodeKML(w io.Writer, warnings []fetcher.Warning, opts Options) error {
+	doc := kmlDocument{Name: opts.Title}
+	styles := map[string]bool{}
+	for _, a := range warnings {
+		c := color(a)
+		styles[c] = true
+
+		p := kmlPlacemark{
+			Name:        a.DisplayType() + " — " + a.Area,
+			Description: kmlDescription(a),
+			StyleURL:    "#alert-" + c,
+		}
+		if a.Time != "" || a.ExpiresTime != "" {
+			p.TimeSpan = &kmlTimeSpan{Begin: a.Time, End: a.ExpiresTime}
+		}
+		if a.Geometry != nil {
+			var mg kmlMultiGeometry
+			for _, rings := range geo.Polygons(a.Geometry.Type, a.Geometry.Coordinates) {
+				if len(rings) == 0 {
+					continue
+				}
+				poly := kmlPolygon{Outer: kmlBoundary{kmlCoordinates(rings[0])}}
+				for _, hole := range rings[1:] {
+					poly.Inner = append(poly.Inner, kmlBoundary{kmlCoordinates(hole)})
+				}
+				mg.Polygons = append(mg.Polygons, poly)
+			}
+			if len(mg.Polygons) > 0 {
+				p.Geometry = &mg
+			}
+		}
+		doc.Placemarks = append(doc.Placemarks, p)
+	}
+
+	colors := make([]string, 0, len(styles))
+	for c := range styles {
+		colors = append(colors, c)
+	}
+	sort.Strings(colors)
+	for _, c := range colors {
+		doc.Styles = append(doc.Styles, kmlStyle{
+			ID:        "alert-" + c,
+			LineColor: kmlColor("ff", c),
+			LineWidth: 2,
+			PolyColor: kmlColor("55", c),
+		})
+	}
+	return writeXML(w, kmlRoot{Document: doc})
+}
+
+// kmlColor converts RGB hex to KML's aabbggrr.
+func kmlColor(alpha, rgb string) string {
+	return strings.ToLower(alpha + rgb[4:6] + rgb[2:4] + rgb[0:2])
+}
+
+func kmlCoordinates(ring [][]float64) string {
+	ps := make([]string, 0, len(ring))
+	for _, p := range ring {
+		if len(p) >= 2 {
+			ps = append(ps, fmt.Sprintf("%g,%g,0", p[0], p[1]))
+		}
+	}
+	return strings.Join(ps, " ")
+}
+
+func kmlDescription(a fetcher.Warning) string {
+	var b strings.Builder
+	if a.Headline != "" {
+		b.WriteString(a.Headline + "\n\n")
+	}
+	b.WriteString(a.Description)
+	if a.Instruction != "" {
+		b.WriteString("\n\n" + a.Instruction)
+	}
+	if a.ExpiresTime != "" {
+		b.WriteString("\n\nExpires: " + a.ExpiresTime)
+	}
+	return b.String()
+}
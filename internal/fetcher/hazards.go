@@ -0,0 +1,39 @@
+package fetcher
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var numberRe = regexp.MustCompile(`\d*\.?\d+`)
+
+// applyHazardTags fills the typed hazard fields from the raw CAP parameters.
+func (w *Warning) applyHazardTags() {
+	w.MaxHailSize = parseNumber(firstParam(w.Parameters, "maxHailSize"))
+	w.MaxWindGust = int(parseNumber(firstParam(w.Parameters, "maxWindGust")))
+	w.TornadoDetection = strings.ToUpper(firstParam(w.Parameters, "tornadoDetection"))
+	w.ThunderstormDamageThreat = strings.ToUpper(firstParam(w.Parameters, "thunderstormDamageThreat"))
+	w.TornadoDamageThreat = strings.ToUpper(firstParam(w.Parameters, "tornadoDamageThreat"))
+}
+
+func firstParam(params map[string][]string, name string) string {
+	if v := params[name]; len(v) > 0 {
+		return strings.TrimSpace(v[0])
+	}
+	return ""
+}
+
+// parseNumber pulls the first number out of values like "1.75", "Up to .75"
+// or "70 MPH". It returns 0 when there is none.
+func parseNumber(s string) float64 {
+	m := numberRe.FindString(s)
+	if m == "" {
+		return 0
+	}
+	n, err := strconv.ParseFloat(m, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
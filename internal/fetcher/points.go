@@ -0,0 +1,43 @@
+package fetcher
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// PointZones returns the UGC codes NWS assigns to a point: its county,
+// public forecast zone and fire weather zone. Zone-based alerts list these
+// codes, so they locate a point for alerts that carry no polygon.
+func (o NWSOptions) PointZones(lat, lon float64) ([]string, error) {
+	base := o.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	var doc struct {
+		Properties struct {
+			County          string `json:"county"`
+			ForecastZone    string `json:"forecastZone"`
+			FireWeatherZone string `json:"fireWeatherZone"`
+		} `json:"properties"`
+	}
+	client := &http.Client{Timeout: 15 * time.Second}
+	u := fmt.Sprintf("%s/points/%.4f,%.4f", strings.TrimSuffix(base, "/"), lat, lon)
+	if err := getJSON(client, u, o.UserAgentString(), &doc); err != nil {
+		return nil, fmt.Errorf("point lookup failed: %w", err)
+	}
+
+	// Each property is a zone URL such as .../zones/county/ALC073.
+	var codes []string
+	for _, z := range []string{doc.Properties.County, doc.Properties.ForecastZone, doc.Properties.FireWeatherZone} {
+		if z != "" {
+			codes = append(codes, strings.ToUpper(path.Base(z)))
+		}
+	}
+	if len(codes) == 0 {
+		return nil, fmt.Errorf("point lookup returned no zones")
+	}
+	return codes, nil
+}
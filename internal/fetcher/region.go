@@ -0,0 +1,101 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/geo"
+)
+
+// pointPadding is how far, in degrees, the map extent reaches around a
+// --point filter so the surrounding storms stay in view.
+const pointPadding = 1.5
+
+// ParsePoint parses a "lat,lon" pair.
+func ParsePoint(s string) (lat, lon float64, err error) {
+	latStr, lonStr, ok := strings.Cut(s, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid point %q: want lat,lon", s)
+	}
+	lat, err = strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, fmt.Errorf("invalid point latitude %q", latStr)
+	}
+	lon, err = strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return 0, 0, fmt.Errorf("invalid point longitude %q", lonStr)
+	}
+	return lat, lon, nil
+}
+
+// Bounds returns the map extent covered by the area, zone or point filter.
+// It reports false when no filter is set or the extent is unknown, such as
+// for marine regions. Zone extents come from the NWS zones endpoint, falling
+// back to the zone's state when that lookup fails.
+func (o NWSOptions) Bounds() (geo.BBox, bool) {
+	switch {
+	case o.Point != "":
+		lat, lon, err := ParsePoint(o.Point)
+		if err != nil {
+			return geo.BBox{}, false
+		}
+		return geo.Empty().Extend(lon, lat).Pad(pointPadding), true
+	case len(o.Zone) > 0:
+		if b, err := o.zoneBounds(); err == nil {
+			return b, true
+		}
+		return stateUnion(o.Zone)
+	case len(o.Area) > 0:
+		return stateUnion(o.Area)
+	}
+	return geo.BBox{}, false
+}
+
+func stateUnion(codes []string) (geo.BBox, bool) {
+	b := geo.Empty()
+	for _, c := range codes {
+		if sb, ok := geo.StateBounds(c); ok {
+			b = b.Union(sb)
+		}
+	}
+	return b, b.Valid()
+}
+
+// zoneBounds looks up zone geometries from the NWS API and returns their extent.
+func (o NWSOptions) zoneBounds() (geo.BBox, error) {
+	base := o.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	q := url.Values{}
+	q.Set("id", strings.ToUpper(strings.Join(o.Zone, ",")))
+	q.Set("include_geometry", "true")
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	page, err := fetchPage(client, strings.TrimSuffix(base, "/")+"/zones?"+q.Encode(), o.UserAgentString())
+	if err != nil {
+		return geo.BBox{}, err
+	}
+
+	b := geo.Empty()
+	for _, raw := range page.Features {
+		var f struct {
+			Geometry *Geometry `json:"geometry"`
+		}
+		if err := json.Unmarshal(raw, &f); err != nil || f.Geometry == nil {
+			continue
+		}
+		if gb, ok := geo.GeometryBounds(f.Geometry.Coordinates); ok {
+			b = b.Union(gb)
+		}
+	}
+	if !b.Valid() {
+		return geo.BBox{}, fmt.Errorf("no zone geometry returned")
+	}
+	return b, nil
+}
@@ -0,0 +1,276 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AlertSource supplies the alerts currently published by some feed. Sources
+// return everything they have; FetchWarnings applies status, expiry and rule
+// filtering on top.
+type AlertSource interface {
+	Alerts() ([]Warning, error)
+}
+
+// Clock is implemented by sources whose notion of "now" differs from the wall
+// clock, such as a replay of a past event.
+type Clock interface {
+	Now() time.Time
+}
+
+// Now returns the current time as seen by src.
+func Now(src AlertSource) time.Time {
+	if c, ok := src.(Clock); ok {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+// snapshotLayout names recorded snapshots so they sort by time.
+const snapshotLayout = "20060102T150405Z"
+
+// NWSSource reads live alerts from the NWS API, following pagination.
+type NWSSource struct {
+	URL       string
+	UserAgent string
+	Client    *http.Client
+	// Record, when set, is a directory that receives a GeoJSON snapshot of
+	// every successful fetch, suitable for ReplaySource.
+	Record string
+}
+
+// NewNWSSource returns a source for the NWS API described by opts.
+func NewNWSSource(opts NWSOptions) (*NWSSource, error) {
+	u, err := opts.AlertsURL()
+	if err != nil {
+		return nil, err
+	}
+	return &NWSSource{
+		URL:       u,
+		UserAgent: opts.UserAgentString(),
+		Client:    &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+// Alerts fetches every page of active alerts.
+func (s *NWSSource) Alerts() ([]Warning, error) {
+	var raw []json.RawMessage
+	url := s.URL
+
+	for url != "" {
+		page, err := fetchPage(s.Client, url, s.UserAgent)
+		if err != nil {
+			return nil, err
+		}
+		raw = append(raw, page.Features...)
+
+		if page.Pagination != nil && page.Pagination.Next != "" {
+			url = page.Pagination.Next
+		} else {
+			url = ""
+		}
+	}
+
+	if s.Record != "" {
+		if err := writeSnapshot(s.Record, time.Now(), raw); err != nil {
+			return nil, err
+		}
+	}
+	return decodeFeatures(raw)
+}
+
+func writeSnapshot(dir string, at time.Time, raw []json.RawMessage) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create record dir: %w", err)
+	}
+	if raw == nil {
+		raw = []json.RawMessage{}
+	}
+	data, err := json.Marshal(struct {
+		Type     string            `json:"type"`
+		Features []json.RawMessage `json:"features"`
+	}{"FeatureCollection", raw})
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	path := filepath.Join(dir, at.UTC().Format(snapshotLayout)+".geojson")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// FileSource reads alerts from a local GeoJSON file, or from every .json and
+// .geojson file in a directory. Files may hold a FeatureCollection or a single
+// Feature, as returned by api.weather.gov/alerts/{id}.
+type FileSource struct {
+	Path string
+}
+
+// Alerts re-reads the file or directory on every call so edits show up live.
+func (s *FileSource) Alerts() ([]Warning, error) {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open alert source: %w", err)
+	}
+	if !info.IsDir() {
+		return readGeoJSONFile(s.Path)
+	}
+
+	files, err := geoJSONFiles(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	var all []Warning
+	for _, f := range files {
+		ws, err := readGeoJSONFile(f)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, ws...)
+	}
+	return all, nil
+}
+
+func geoJSONFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert directory: %w", err)
+	}
+	var files []string
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || (ext != ".json" && ext != ".geojson") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, e.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func readGeoJSONFile(path string) ([]Warning, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var head struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if head.Type == "Feature" {
+		return decodeFeatures([]json.RawMessage{data})
+	}
+
+	var fc featureCollection
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return decodeFeatures(fc.Features)
+}
+
+// ReplaySource steps through snapshots recorded by NWSSource.Record. Time
+// starts at the first snapshot when the source is first read and advances at
+// Speed times the wall clock; each read returns the latest snapshot at or
+// before the replay time. Its Now method reports the replay time so expiry
+// filtering matches the recorded event rather than today.
+type ReplaySource struct {
+	Dir   string
+	Speed float64
+	// Loop restarts the timeline after the last snapshot instead of holding it.
+	Loop bool
+
+	mu        sync.Mutex
+	frames    []replayFrame
+	startWall time.Time
+	current   int
+	warnings  []Warning
+}
+
+type replayFrame struct {
+	at   time.Time
+	path string
+}
+
+// Now returns the replay time.
+func (s *ReplaySource) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil || len(s.frames) == 0 {
+		return time.Now()
+	}
+	return s.replayTime()
+}
+
+// Alerts returns the snapshot for the current replay time.
+func (s *ReplaySource) Alerts() ([]Warning, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if len(s.frames) == 0 {
+		return nil, fmt.Errorf("no snapshots in %s", s.Dir)
+	}
+
+	at := s.replayTime()
+	idx := sort.Search(len(s.frames), func(i int) bool { return s.frames[i].at.After(at) }) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if s.warnings == nil || idx != s.current {
+		ws, err := readGeoJSONFile(s.frames[idx].path)
+		if err != nil {
+			return nil, err
+		}
+		s.current, s.warnings = idx, ws
+	}
+	return s.warnings, nil
+}
+
+// load indexes the snapshot directory and starts the clock on first use.
+func (s *ReplaySource) load() error {
+	if s.frames != nil {
+		return nil
+	}
+	files, err := geoJSONFiles(s.Dir)
+	if err != nil {
+		return err
+	}
+	frames := []replayFrame{}
+	for _, f := range files {
+		name := strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))
+		at, err := time.Parse(snapshotLayout, name)
+		if err != nil {
+			continue
+		}
+		frames = append(frames, replayFrame{at: at, path: f})
+	}
+	sort.Slice(frames, func(i, j int) bool { return frames[i].at.Before(frames[j].at) })
+	s.frames = frames
+	s.startWall = time.Now()
+	return nil
+}
+
+func (s *ReplaySource) replayTime() time.Time {
+	speed := s.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+	first, last := s.frames[0].at, s.frames[len(s.frames)-1].at
+	elapsed := time.Duration(float64(time.Since(s.startWall)) * speed)
+	if s.Loop && last.After(first) {
+		elapsed %= last.Sub(first) + time.Second
+	}
+	return first.Add(elapsed)
+}
@@ -0,0 +1,56 @@
+package fetcher
+
+import "strings"
+
+// Alert tiers sit above CAP severity: every Extreme tornado warning has the
+// same severity, but a Tornado Emergency is not an ordinary tornado warning.
+const (
+	TierEmergency = "emergency"
+	TierPDS       = "pds"
+)
+
+// TierRank orders tiers from ordinary (0) to emergency (2).
+func TierRank(tier string) int {
+	switch tier {
+	case TierEmergency:
+		return 2
+	case TierPDS:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// DisplayType names an alert by its tier: "Tornado Emergency" for an
+// emergency-tier Tornado Warning, "PDS Tornado Watch" for a PDS watch, and
+// the plain event name otherwise.
+func (w Warning) DisplayType() string {
+	switch w.Tier {
+	case TierEmergency:
+		if strings.Contains(w.Type, " Warning") {
+			return strings.Replace(w.Type, " Warning", " Emergency", 1)
+		}
+		return w.Type + " Emergency"
+	case TierPDS:
+		return "PDS " + w.Type
+	default:
+		return w.Type
+	}
+}
+
+// classifyTier detects Tornado/Flash Flood Emergencies and Particularly
+// Dangerous Situations from the product text and damage-threat tags.
+func classifyTier(w Warning) string {
+	text := strings.ToUpper(w.Headline + "\n" + w.Description)
+	switch {
+	case w.TornadoDamageThreat == "CATASTROPHIC",
+		strings.Contains(text, "TORNADO EMERGENCY"),
+		strings.Contains(text, "FLASH FLOOD EMERGENCY"):
+		return TierEmergency
+	case w.TornadoDamageThreat == "CONSIDERABLE",
+		strings.Contains(text, "PARTICULARLY DANGEROUS SITUATION"):
+		return TierPDS
+	default:
+		return ""
+	}
+}
@@ -5,194 +5,361 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/boundaries"
+	"github.com/Zachdehooge/warnings-dashboard/internal/rules"
 )
 
-// Warning Represents a weather warning
+// DefaultBaseURL is the public NWS API.
+const DefaultBaseURL = "https://api.weather.gov"
+
+// NWSOptions selects which NWS API and which alerts a source requests. Area,
+// Zone, Region and Point map to the API's query parameters of the same name;
+// the API accepts only one of them per request.
+type NWSOptions struct {
+	BaseURL string `yaml:"baseURL"`
+	// Contact is an email or URL added to the User-Agent, as NWS asks of clients.
+	Contact string `yaml:"contact"`
+	// UserAgent replaces the generated User-Agent entirely when set.
+	UserAgent string   `yaml:"userAgent"`
+	Area      []string `yaml:"area"`
+	Zone      []string `yaml:"zone"`
+	Region    string   `yaml:"region"`
+	// Point is a "lat,lon" pair; only alerts covering it are returned.
+	Point string `yaml:"point"`
+	// Events limits the request to these NWS event names; empty requests all
+	// events and leaves the choice to the filter rules.
+	Events []string `yaml:"events"`
+}
+
+// AlertsURL returns the active-alerts URL for these options.
+func (o NWSOptions) AlertsURL() (string, error) {
+	scopes := 0
+	for _, set := range []bool{len(o.Area) > 0, len(o.Zone) > 0, o.Region != "", o.Point != ""} {
+		if set {
+			scopes++
+		}
+	}
+	if scopes > 1 {
+		return "", fmt.Errorf("only one of area, zone, region and point may be set")
+	}
+
+	base := o.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	q := url.Values{}
+	q.Set("status", "actual")
+	if len(o.Area) > 0 {
+		q.Set("area", strings.ToUpper(strings.Join(o.Area, ",")))
+	}
+	if len(o.Zone) > 0 {
+		q.Set("zone", strings.ToUpper(strings.Join(o.Zone, ",")))
+	}
+	if o.Region != "" {
+		q.Set("region", strings.ToUpper(o.Region))
+	}
+	if o.Point != "" {
+		lat, lon, err := ParsePoint(o.Point)
+		if err != nil {
+			return "", err
+		}
+		q.Set("point", fmt.Sprintf("%.4f,%.4f", lat, lon))
+	}
+	if len(o.Events) > 0 {
+		q.Set("event", strings.Join(o.Events, ","))
+	}
+	return strings.TrimSuffix(base, "/") + "/alerts/active?" + q.Encode(), nil
+}
+
+// UserAgentString returns the User-Agent header to send to NWS.
+func (o NWSOptions) UserAgentString() string {
+	if o.UserAgent != "" {
+		return o.UserAgent
+	}
+	return UserAgent(o.Contact)
+}
+
+// UserAgent identifies the dashboard to upstream services, which reject
+// anonymous clients. contact is appended when set.
+func UserAgent(contact string) string {
+	if contact == "" {
+		return "warnings-dashboard/1.0 (github.com/Zachdehooge/warnings-dashboard)"
+	}
+	return "warnings-dashboard/1.0 (github.com/Zachdehooge/warnings-dashboard; " + contact + ")"
+}
+
+// Warning Represents a weather warning. It is also the shape written to
+// warnings.json and consumed by the browser JS.
 type Warning struct {
 	ID          string      `json:"id"`
 	Type        string      `json:"type"`
+	Headline    string      `json:"headline"`
 	Description string      `json:"description"`
+	Instruction string      `json:"instruction"`
 	Area        string      `json:"area"`
 	Severity    string      `json:"severity"`
+	Certainty   string      `json:"certainty"`
+	Urgency     string      `json:"urgency"`
+	Status      string      `json:"status"`
+	MessageType string      `json:"messageType"`
+	SenderName  string      `json:"senderName"`
 	Time        string      `json:"time"`
+	Onset       string      `json:"onset"`
+	Ends        string      `json:"ends"`
 	ExpiresTime string      `json:"expiresTime"`
-	Geometry    interface{} `json:"geometry"`
+	Geometry    *Geometry   `json:"geometry"`
 	UGC         []string    `json:"ugc"`
 	SAME        []string    `json:"same"`
-}
+	References  []Reference `json:"references"`
+	// Parameters holds the product-specific CAP parameters, such as
+	// maxHailSize or VTEC, keyed by name.
+	Parameters map[string][]string `json:"parameters"`
 
-// FetchWarnings retrieves weather warnings from the National Weather Service API
-func FetchWarnings() ([]Warning, error) {
-	// NWS API endpoint for active alerts
-	url := "https://api.weather.gov/alerts/active"
+	// GeometryDerived is set when the NWS issued no polygon and Geometry is
+	// the combined outline of the counties and zones in UGC.
+	GeometryDerived bool `json:"geometryDerived"`
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch warnings: %w", err)
-	}
-	defer resp.Body.Close()
+	// Hazard tags parsed from Parameters. MaxHailSize is in inches and
+	// MaxWindGust in mph; zero means the product did not say.
+	MaxHailSize              float64 `json:"maxHailSize"`
+	MaxWindGust              int     `json:"maxWindGust"`
+	TornadoDetection         string  `json:"tornadoDetection"`
+	ThunderstormDamageThreat string  `json:"thunderstormDamageThreat"`
+	TornadoDamageThreat      string  `json:"tornadoDamageThreat"`
 
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned non-200 status: %d", resp.StatusCode)
+	// Tier is TierEmergency, TierPDS or empty for an ordinary alert.
+	Tier string `json:"tier"`
+	// Lifecycle is set by the poller's lifecycle tracker: new, continued,
+	// updated, extended or cancelled. It is empty outside the poller.
+	Lifecycle string `json:"lifecycle"`
+}
+
+// RuleAlert returns the fields of w that filter rules match on.
+func (w Warning) RuleAlert() rules.Alert {
+	return rules.Alert{
+		Event:       w.Type,
+		Description: w.Description,
+		Severity:    w.Severity,
+		Certainty:   w.Certainty,
+		Urgency:     w.Urgency,
+		UGC:         w.UGC,
+		Tier:        w.Tier,
 	}
+}
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// Parse JSON response
-	var apiResponse struct {
-		Features []struct {
-			Geometry   interface{} `json:"geometry"` // Added to capture geometry
-			Properties struct {
-				ID          string `json:"id"`
-				Event       string `json:"event"`
-				Description string `json:"description"`
-				Severity    string `json:"severity"`
-				Sent        string `json:"sent"`
-				Expires     string `json:"expires"`
-				Headline    string `json:"headline"`
-				Area        string `json:"areaDesc"`
-				Geocode     struct {
-					UGC  []string `json:"UGC"`
-					SAME []string `json:"SAME"`
-				} `json:"geocode"`
-			} `json:"properties"`
-		} `json:"features"`
-	}
-
-	err = json.Unmarshal(body, &apiResponse)
+// Reference points at an earlier alert that this one updates or cancels.
+type Reference struct {
+	ID         string `json:"id"`
+	Identifier string `json:"identifier"`
+	Sender     string `json:"sender"`
+	Sent       string `json:"sent"`
+}
+
+// Geometry mirrors the GeoJSON geometry object the frontend expects.
+type Geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// feature is a single alert in an NWS GeoJSON FeatureCollection.
+type feature struct {
+	Geometry   *Geometry `json:"geometry"`
+	Properties struct {
+		ID          string `json:"id"`
+		Event       string `json:"event"`
+		Headline    string `json:"headline"`
+		Description string `json:"description"`
+		Instruction string `json:"instruction"`
+		AreaDesc    string `json:"areaDesc"`
+		Severity    string `json:"severity"`
+		Certainty   string `json:"certainty"`
+		Urgency     string `json:"urgency"`
+		Status      string `json:"status"`
+		MessageType string `json:"messageType"`
+		SenderName  string `json:"senderName"`
+		Sent        string `json:"sent"`
+		Onset       string `json:"onset"`
+		Ends        string `json:"ends"`
+		Expires     string `json:"expires"`
+		Geocode     struct {
+			UGC  []string `json:"UGC"`
+			SAME []string `json:"SAME"`
+		} `json:"geocode"`
+		References []struct {
+			ID         string `json:"@id"`
+			Identifier string `json:"identifier"`
+			Sender     string `json:"sender"`
+			Sent       string `json:"sent"`
+		} `json:"references"`
+		Parameters map[string][]string `json:"parameters"`
+	} `json:"properties"`
+}
+
+type featureCollection struct {
+	Features   []json.RawMessage `json:"features"`
+	Pagination *struct {
+		Next string `json:"next"`
+	} `json:"pagination"`
+}
+
+// FetchWarnings reads the alerts currently published by src and returns the
+// actual, unexpired ones allowed by rs. A nil rule set keeps everything.
+func FetchWarnings(src AlertSource, rs *rules.Set) ([]Warning, error) {
+	warnings, err := src.Alerts()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		return nil, err
 	}
+	warnings = filterWarnings(warnings, rs, Now(src))
+	resolveGeometry(warnings)
+	return warnings, nil
+}
 
-	// Transform API response to our Warning struct
-	warnings := make([]Warning, 0, len(apiResponse.Features))
-	for _, feature := range apiResponse.Features {
-		// Filter out unwanted warning types
-		if isFilteredWarning(feature.Properties.Event) {
+// resolveGeometry gives alerts issued without a polygon, such as most
+// watches, the outline of the counties and zones they list, so the map and
+// point checks treat them like any other alert.
+func resolveGeometry(warnings []Warning) {
+	for i := range warnings {
+		w := &warnings[i]
+		if w.Geometry != nil {
 			continue
 		}
-
-		if isFilteredWords(feature.Properties.Description) {
+		polygons := boundaries.Outline(w.Type, w.UGC)
+		if len(polygons) == 0 {
 			continue
 		}
-
-		warnings = append(warnings, Warning{
-			ID:          feature.Properties.ID,
-			Type:        feature.Properties.Event,
-			Description: feature.Properties.Description,
-			Area:        feature.Properties.Area,
-			Severity:    feature.Properties.Severity,
-			Time:        feature.Properties.Sent,
-			ExpiresTime: feature.Properties.Expires,
-			Geometry:    feature.Geometry, // Store the geometry data
-			UGC:         feature.Properties.Geocode.UGC,
-			SAME:        feature.Properties.Geocode.SAME,
-		})
+		coords, err := json.Marshal(polygons)
+		if err != nil {
+			continue
+		}
+		w.Geometry = &Geometry{Type: "MultiPolygon", Coordinates: coords}
+		w.GeometryDerived = true
 	}
+}
 
-	return warnings, nil
+func fetchPage(client *http.Client, url, userAgent string) (*featureCollection, error) {
+	var page featureCollection
+	if err := getJSON(client, url, userAgent, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
 }
 
-func isFilteredWords(words string) bool {
-	words = strings.ToLower(words)
+// getJSON fetches an NWS API document and decodes it into v.
+func getJSON(client *http.Client, url, userAgent string, v interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/geo+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
 
-	filteredTypes := []string{
-		"fire",
+	// Read response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	for _, filteredType := range filteredTypes {
-		if strings.Contains(words, filteredType) {
-			return true
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		snip := body
+		if len(snip) > 200 {
+			snip = snip[:200]
 		}
+		return fmt.Errorf("API returned non-200 status: %d: %s", resp.StatusCode, string(snip))
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse JSON: %w", err)
 	}
+	return nil
+}
+
+// decodeFeatures transforms raw GeoJSON features to our Warning struct.
+func decodeFeatures(raw []json.RawMessage) ([]Warning, error) {
+	warnings := make([]Warning, 0, len(raw))
+	for _, r := range raw {
+		var f feature
+		if err := json.Unmarshal(r, &f); err != nil {
+			return nil, fmt.Errorf("failed to parse feature: %w", err)
+		}
+		p := f.Properties
 
-	return false
-}
-
-// isFilteredWarning checks if a warning should be filtered out
-func isFilteredWarning(eventType string) bool {
-	// Convert to lowercase for case-insensitive matching
-	lowercaseEvent := strings.ToLower(eventType)
-
-	// ! UNCOMMENT BELOW TO KEEP WINTER STORM WARNINGS
-	/*if strings.Contains(lowercaseEvent, "winter storm") {
-		return false
-	}*/
-
-	if strings.Contains(lowercaseEvent, "severe thunderstorm") {
-		return false
-	}
-
-	if strings.Contains(lowercaseEvent, "tornado") {
-		return false
-	}
-
-	// ! List of warning types to filter out
-	filteredTypes := []string{
-		"storm warning",
-		"hazardous seas watch",
-		"tsunami warning",
-		"snow squall warning",
-		"winter storm warning",
-		"winter storm watch",
-		"fire warning",
-		"storm watch",
-		"freeze watch",
-		"flood",
-		"winter weather advisory",
-		"extreme heat warning",
-		"frost advisory",
-		"freeze warning",
-		"gale warning",
-		"test message",
-		"high wind warning",
-		"wind advisory",
-		"high wind watch",
-		"heat advisory",
-		"dense fog advisory",
-		"blowing dust advisory",
-		"dust storm warning",
-		"small craft advisory",
-		"red flag warning",
-		"air quality alert",
-		"heavy freezing spray warning",
-		"fire weather watch",
-		"gale watch",
-		"blowing dust warning",
-		"hydrologic outlook",
-		"marine",
-		"coastal flood",
-		"river flood",
-		"flash flood",
-		"high surf",
-		"rip current",
-		"beach hazard",
-		"coastal hazard",
-		"coastal erosion",
-		"blizzard warning",
-		"extreme cold watch",
-		"extreme cold warning",
-		"hazardous seas warning",
-		"cold weather advisory",
-		"avalanche warning",
-		"avalanche advisory",
-		"ashfall advisory",
-		"avalanche watch",
-		"freezing fog advisory",
-	}
-
-	// Check if the event type contains any filtered keywords
-	for _, filteredType := range filteredTypes {
-		if strings.Contains(lowercaseEvent, filteredType) {
-			return true
+		ugc := p.Geocode.UGC
+		if ugc == nil {
+			ugc = []string{}
 		}
+		same := p.Geocode.SAME
+		if same == nil {
+			same = []string{}
+		}
+
+		refs := make([]Reference, 0, len(p.References))
+		for _, r := range p.References {
+			refs = append(refs, Reference{ID: r.ID, Identifier: r.Identifier, Sender: r.Sender, Sent: r.Sent})
+		}
+		params := p.Parameters
+		if params == nil {
+			params = map[string][]string{}
+		}
+
+		w := Warning{
+			ID:          p.ID,
+			Type:        p.Event,
+			Headline:    p.Headline,
+			Description: p.Description,
+			Instruction: p.Instruction,
+			Area:        p.AreaDesc,
+			Severity:    p.Severity,
+			Certainty:   p.Certainty,
+			Urgency:     p.Urgency,
+			Status:      p.Status,
+			MessageType: p.MessageType,
+			SenderName:  p.SenderName,
+			Time:        p.Sent,
+			Onset:       p.Onset,
+			Ends:        p.Ends,
+			ExpiresTime: p.Expires,
+			Geometry:    f.Geometry,
+			UGC:         ugc,
+			SAME:        same,
+			References:  refs,
+			Parameters:  params,
+		}
+		w.applyHazardTags()
+		w.Tier = classifyTier(w)
+		warnings = append(warnings, w)
 	}
+	return warnings, nil
+}
 
-	return false
+// filterWarnings drops non-actual, expired and rule-excluded alerts.
+func filterWarnings(warnings []Warning, rs *rules.Set, now time.Time) []Warning {
+	kept := make([]Warning, 0, len(warnings))
+	for _, w := range warnings {
+		if w.Status != "Actual" {
+			continue
+		}
+		// Skip already-expired alerts
+		if w.ExpiresTime != "" {
+			if exp, err := time.Parse(time.RFC3339, w.ExpiresTime); err == nil && exp.Before(now) {
+				continue
+			}
+		}
+		// Filter out unwanted warning types
+		if !rs.Allow(w.RuleAlert()) {
+			continue
+		}
+		kept = append(kept, w)
+	}
+	return kept
 }
@@ -0,0 +1,50 @@
+package fetcher
+
+import (
+	"testing"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/boundaries"
+	"github.com/Zachdehooge/warnings-dashboard/internal/geo"
+)
+
+func TestFetchWarningsOutlinesWatches(t *testing.T) {
+	if _, ok := boundaries.Lookup("Tornado Watch", "ALC073"); !ok {
+		t.Skip("no outlines embedded; run go generate ./internal/boundaries")
+	}
+
+	warnings, err := FetchWarnings(&FileSource{Path: "testdata/watch.geojson"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("got %d warnings, want 2", len(warnings))
+	}
+	watch, warning := warnings[0], warnings[1]
+
+	if watch.Geometry == nil || watch.Geometry.Type != "MultiPolygon" || !watch.GeometryDerived {
+		t.Fatalf("watch geometry %+v, derived %v; want a derived MultiPolygon", watch.Geometry, watch.GeometryDerived)
+	}
+	// Birmingham is in Jefferson County, Columbiana in Shelby County, and
+	// Montgomery in neither.
+	for _, p := range []struct {
+		name     string
+		lat, lon float64
+		want     bool
+	}{
+		{"Birmingham", 33.52, -86.81, true},
+		{"Columbiana", 33.18, -86.61, true},
+		{"Montgomery", 32.37, -86.30, false},
+	} {
+		if got := geo.ContainsPoint(watch.Geometry.Type, watch.Geometry.Coordinates, p.lon, p.lat); got != p.want {
+			t.Errorf("outline contains %s: %v, want %v", p.name, got, p.want)
+		}
+	}
+	// The two counties share a border, which is dissolved away.
+	if n := len(geo.Polygons(watch.Geometry.Type, watch.Geometry.Coordinates)); n != 1 {
+		t.Errorf("outline has %d polygons, want 1", n)
+	}
+
+	if warning.GeometryDerived || warning.Geometry == nil || warning.Geometry.Type != "Polygon" {
+		t.Errorf("issued polygon replaced: %+v, derived %v", warning.Geometry, warning.GeometryDerived)
+	}
+}
@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/coverage"
+	"github.com/Zachdehooge/warnings-dashboard/internal/lifecycle"
+)
+
+// Delta is what a poll cycle changed, pushed to live clients instead of the
+// full payload. Seq increases by one per delta; a client that sees a gap has
+// missed one and should re-read warnings.json.
+type Delta struct {
+	Seq     int64         `json:"seq"`
+	Added   []WarningJSON `json:"added"`
+	Updated []WarningJSON `json:"updated"`
+	Removed []string      `json:"removed"`
+	// MesoscaleDiscussions is the complete MCD list, or null when unchanged.
+	MesoscaleDiscussions []MesoscaleDiscussionJSON `json:"mesoscaleDiscussions"`
+	Events               []lifecycle.Event         `json:"events"`
+	// Locations and LocationEvents are sent in full on every delta.
+	Locations      []coverage.Status `json:"locations"`
+	LocationEvents []coverage.Event  `json:"locationEvents"`
+	LastUpdated    string            `json:"lastUpdated"`
+	Counter        int               `json:"counter"`
+	UpdatedAtUTC   int64             `json:"updatedAtUTC"`
+	SourceOffset   int64             `json:"sourceOffset"`
+}
+
+// deltaState remembers a fingerprint of everything the last payload sent.
+type deltaState struct {
+	seq       int64
+	alerts    map[string][32]byte
+	mcds      [32]byte
+	events    [32]byte
+	locations [32]byte
+	baseline  bool
+}
+
+// diff compares payload with the previous cycle and returns the delta, or
+// false when nothing a client displays has changed. It stamps payload.Seq.
+func (d *deltaState) diff(payload *PolledPayload) (Delta, bool) {
+	delta := Delta{
+		Added:          []WarningJSON{},
+		Updated:        []WarningJSON{},
+		Removed:        []string{},
+		Events:         payload.Events,
+		Locations:      payload.Locations,
+		LocationEvents: payload.LocationEvents,
+		LastUpdated:    payload.LastUpdated,
+		Counter:        payload.Counter,
+		UpdatedAtUTC:   payload.UpdatedAtUTC,
+		SourceOffset:   payload.SourceOffset,
+	}
+
+	alerts := make(map[string][32]byte, len(payload.Warnings))
+	for _, w := range payload.Warnings {
+		// Lifecycle moves from "new" to "continued" on the next cycle by
+		// itself, which is not a change to the alert.
+		own := w
+		own.Lifecycle = ""
+		sum := fingerprint(own)
+		alerts[w.ID] = sum
+		prev, ok := d.alerts[w.ID]
+		switch {
+		case !ok:
+			delta.Added = append(delta.Added, w)
+		case prev != sum:
+			delta.Updated = append(delta.Updated, w)
+		}
+	}
+	for id := range d.alerts {
+		if _, ok := alerts[id]; !ok {
+			delta.Removed = append(delta.Removed, id)
+		}
+	}
+
+	mcds := fingerprint(payload.MesoscaleDiscussions)
+	if mcds != d.mcds || !d.baseline {
+		delta.MesoscaleDiscussions = payload.MesoscaleDiscussions
+	}
+	events := fingerprint(payload.Events)
+	locations := fingerprint([]interface{}{payload.Locations, payload.LocationEvents})
+
+	changed := len(delta.Added) > 0 || len(delta.Updated) > 0 || len(delta.Removed) > 0 ||
+		delta.MesoscaleDiscussions != nil || events != d.events || locations != d.locations
+	d.alerts, d.mcds, d.events, d.locations, d.baseline = alerts, mcds, events, locations, true
+	if changed {
+		d.seq++
+	}
+	payload.Seq = d.seq
+	delta.Seq = d.seq
+	return delta, changed
+}
+
+func fingerprint(v interface{}) [32]byte {
+	data, _ := json.Marshal(v)
+	return sha256.Sum256(data)
+}
@@ -0,0 +1,88 @@
+package generator
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func ids(ws []WarningJSON) []string {
+	out := []string{}
+	for _, w := range ws {
+		out = append(out, w.ID)
+	}
+	return out
+}
+
+func TestDeltaDiff(t *testing.T) {
+	warning := func(id, lifecycle, expires string) WarningJSON {
+		return WarningJSON{ID: id, Type: "Tornado Warning", Lifecycle: lifecycle, ExpiresTime: expires}
+	}
+	cycles := []struct {
+		name     string
+		warnings []WarningJSON
+		changed  bool
+		seq      int64
+		added    []string
+		updated  []string
+		removed  []string
+	}{
+		{
+			name:     "first cycle",
+			warnings: []WarningJSON{warning("A", "new", "18:30"), warning("B", "new", "18:45")},
+			changed:  true, seq: 1,
+			added: []string{"A", "B"}, updated: []string{}, removed: []string{},
+		},
+		{
+			name:     "only lifecycle changes",
+			warnings: []WarningJSON{warning("A", "continued", "18:30"), warning("B", "continued", "18:45")},
+			changed:  false, seq: 1,
+			added: []string{}, updated: []string{}, removed: []string{},
+		},
+		{
+			name:     "added, updated and removed",
+			warnings: []WarningJSON{warning("A", "continued", "19:00"), warning("C", "new", "19:15")},
+			changed:  true, seq: 2,
+			added: []string{"C"}, updated: []string{"A"}, removed: []string{"B"},
+		},
+		{
+			name:     "nothing changes",
+			warnings: []WarningJSON{warning("A", "continued", "19:00"), warning("C", "continued", "19:15")},
+			changed:  false, seq: 2,
+			added: []string{}, updated: []string{}, removed: []string{},
+		},
+		{
+			name:     "all removed",
+			warnings: []WarningJSON{},
+			changed:  true, seq: 3,
+			added: []string{}, updated: []string{}, removed: []string{"A", "C"},
+		},
+	}
+
+	var d deltaState
+	for _, c := range cycles {
+		payload := PolledPayload{Warnings: c.warnings, MesoscaleDiscussions: []MesoscaleDiscussionJSON{}}
+		delta, changed := d.diff(&payload)
+		if changed != c.changed {
+			t.Errorf("%s: changed = %v, want %v", c.name, changed, c.changed)
+		}
+		if delta.Seq != c.seq || payload.Seq != c.seq {
+			t.Errorf("%s: delta seq %d, payload seq %d, want %d", c.name, delta.Seq, payload.Seq, c.seq)
+		}
+		sort.Strings(delta.Removed)
+		got := [][]string{ids(delta.Added), ids(delta.Updated), delta.Removed}
+		want := [][]string{c.added, c.updated, c.removed}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: added, updated, removed = %q, want %q", c.name, got, want)
+		}
+		for _, w := range delta.Added {
+			if w.Lifecycle != "new" {
+				t.Errorf("%s: added %s with lifecycle %q", c.name, w.ID, w.Lifecycle)
+			}
+		}
+		// The first delta carries the MCD list; later ones only when it changes.
+		if (delta.MesoscaleDiscussions != nil) != (c.seq == 1 && changed) {
+			t.Errorf("%s: MCDs sent: %v", c.name, delta.MesoscaleDiscussions != nil)
+		}
+	}
+}
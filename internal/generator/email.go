@@ -0,0 +1,120 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+)
+
+// cardColors are the dashboard's card colours for one severity class:
+// accent (border, heading and badge), background and badge text.
+type cardColors struct {
+	Accent, Background, BadgeText string
+}
+
+// emailColors mirrors the .warning-card rules of the dashboard stylesheet.
+// Mail clients drop <style> blocks, so emails carry the colours inline.
+var emailColors = map[string]cardColors{
+	"tornado":       {"#FF1493", "#2d0a1f", "#000"},
+	"tstorm":        {"#FF0000", "#2a0a0a", "#fff"},
+	"tornado-watch": {"#FFFF00", "#2a2a0a", "#000"},
+	"watch":         {"#FFA500", "#2a1a0a", "#000"},
+	"severe":        {"#FF4444", "#2a0f0f", "#fff"},
+	"moderate":      {"#FFAA00", "#2a1f0a", "#000"},
+	"sps":           {"#66B2FF", "#1a2a3a", "#000"},
+	"emergency":     {"#FF00FF", "#33002e", "#000"},
+	"pds":           {"#FF6A00", "#2e1400", "#000"},
+}
+
+var defaultEmailColors = cardColors{"#ffffff", "#1a1a1a", "#000"}
+
+// emailCard is a TemplateWarning with what the email card template needs.
+type emailCard struct {
+	TemplateWarning
+	Colors     cardColors
+	HazardTags []string
+}
+
+var emailCardTemplate = template.Must(template.New("cards").Parse(`
+{{- define "header" -}}
+<div style="padding:10px 15px;border-radius:8px 8px 0 0;background:{{.Colors.Background}};border:2px solid {{.Colors.Accent}};border-bottom:none;">
+<h2 style="margin:0;font-size:16px;text-transform:uppercase;letter-spacing:1px;color:{{.Colors.Accent}};">{{.Type}}</h2>
+</div>
+{{- end -}}
+{{- define "card" -}}
+<div style="padding:16px;border-radius:0 0 8px 8px;margin-bottom:15px;background:{{.Colors.Background}};border:2px solid {{.Colors.Accent}};border-top:none;color:#fff;font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,Helvetica,Arial,sans-serif;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="margin-bottom:10px;"><tr>
+<td style="font-size:18px;font-weight:600;color:{{.Colors.Accent}};">{{.Type}}</td>
+<td align="right" valign="top"><span style="font-size:12px;font-weight:600;padding:4px 8px;border-radius:4px;text-transform:uppercase;background:{{.Colors.Accent}};color:{{.Colors.BadgeText}};">{{.Severity}}</span></td>
+</tr></table>
+<div style="font-size:16px;font-weight:500;margin-bottom:10px;">{{.Area}}</div>
+{{- if .HazardTags}}
+<div style="margin-bottom:10px;">{{range .HazardTags}}<span style="display:inline-block;margin:0 6px 6px 0;font-size:12px;font-weight:600;padding:2px 8px;border-radius:10px;background:#333;color:#fff;text-transform:uppercase;">{{.}}</span>{{end}}</div>
+{{- end}}
+{{- if .SenderName}}
+<div style="font-size:13px;color:#888;margin-bottom:10px;">{{.SenderName}}{{if and .MessageType (ne .MessageType "Alert")}} · {{.MessageType}}{{end}}</div>
+{{- end}}
+<div style="font-size:14px;color:#aaa;margin-bottom:12px;white-space:pre-wrap;">{{.Description}}</div>
+{{- if .Instruction}}
+<div style="font-size:14px;color:#ddd;margin-bottom:12px;padding:8px 10px;border-left:3px solid #777;background:#222;"><strong>Instructions:</strong> {{.Instruction}}</div>
+{{- end}}
+<div style="font-size:13px;color:#888;padding-top:10px;border-top:1px solid #333;">Expires: {{.LocalExpires}}</div>
+</div>
+{{- end -}}
+{{- range .}}{{if eq .Severity "Header"}}{{template "header" .}}{{else}}{{template "card" .}}{{end}}
+{{end -}}
+`))
+
+// EmailCards renders warnings as the dashboard's alert cards, grouped under
+// type headers in the same order as the page, with inline styles for email.
+func EmailCards(warnings []fetcher.Warning) (template.HTML, error) {
+	tws := convertWarnings(warnings)
+	cards := make([]emailCard, len(tws))
+	for i, tw := range tws {
+		class := tw.SeverityClass
+		if class == "header" && i+1 < len(tws) {
+			class = tws[i+1].SeverityClass
+		}
+		colors, ok := emailColors[class]
+		if !ok {
+			colors = defaultEmailColors
+		}
+		cards[i] = emailCard{TemplateWarning: tw, Colors: colors, HazardTags: hazardTags(tw.Warning)}
+	}
+
+	var buf bytes.Buffer
+	if err := emailCardTemplate.Execute(&buf, cards); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// WarningTypeCounts counts warnings by the group they are listed under,
+// most important group first.
+func WarningTypeCounts(warnings []fetcher.Warning) []TypeCount {
+	return sortedWarningTypeCounts(warnings)
+}
+
+// hazardTags mirrors the page's renderHazardTags.
+func hazardTags(w fetcher.Warning) []string {
+	var tags []string
+	if w.TornadoDetection != "" {
+		tags = append(tags, "Tornado "+strings.ToLower(w.TornadoDetection))
+	}
+	if w.TornadoDamageThreat != "" {
+		tags = append(tags, strings.ToLower(w.TornadoDamageThreat)+" tornado damage")
+	}
+	if w.ThunderstormDamageThreat != "" {
+		tags = append(tags, strings.ToLower(w.ThunderstormDamageThreat)+" damage")
+	}
+	if w.MaxHailSize > 0 {
+		tags = append(tags, fmt.Sprintf(`Hail %.2f"`, w.MaxHailSize))
+	}
+	if w.MaxWindGust > 0 {
+		tags = append(tags, fmt.Sprintf("Wind %d mph", w.MaxWindGust))
+	}
+	return tags
+}
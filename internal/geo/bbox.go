@@ -0,0 +1,92 @@
+package geo
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// BBox is a longitude/latitude bounding box in degrees.
+type BBox struct {
+	MinLon float64 `json:"minLon"`
+	MinLat float64 `json:"minLat"`
+	MaxLon float64 `json:"maxLon"`
+	MaxLat float64 `json:"maxLat"`
+}
+
+// Empty returns a box that any Extend or Union will replace.
+func Empty() BBox {
+	return BBox{MinLon: math.Inf(1), MinLat: math.Inf(1), MaxLon: math.Inf(-1), MaxLat: math.Inf(-1)}
+}
+
+// Valid reports whether the box covers at least one point.
+func (b BBox) Valid() bool {
+	return b.MinLon <= b.MaxLon && b.MinLat <= b.MaxLat
+}
+
+// Extend grows the box to include a point.
+func (b BBox) Extend(lon, lat float64) BBox {
+	return BBox{
+		MinLon: math.Min(b.MinLon, lon),
+		MinLat: math.Min(b.MinLat, lat),
+		MaxLon: math.Max(b.MaxLon, lon),
+		MaxLat: math.Max(b.MaxLat, lat),
+	}
+}
+
+// Union returns the smallest box containing both boxes.
+func (b BBox) Union(o BBox) BBox {
+	if !o.Valid() {
+		return b
+	}
+	return b.Extend(o.MinLon, o.MinLat).Extend(o.MaxLon, o.MaxLat)
+}
+
+// Pad grows the box by deg degrees on every side.
+func (b BBox) Pad(deg float64) BBox {
+	return BBox{MinLon: b.MinLon - deg, MinLat: b.MinLat - deg, MaxLon: b.MaxLon + deg, MaxLat: b.MaxLat + deg}
+}
+
+// Intersects reports whether two boxes overlap.
+func (b BBox) Intersects(o BBox) bool {
+	return b.MinLon <= o.MaxLon && o.MinLon <= b.MaxLon && b.MinLat <= o.MaxLat && o.MinLat <= b.MaxLat
+}
+
+// Contains reports whether a point lies inside the box.
+func (b BBox) Contains(lon, lat float64) bool {
+	return lon >= b.MinLon && lon <= b.MaxLon && lat >= b.MinLat && lat <= b.MaxLat
+}
+
+// LatLngs returns the box as Leaflet bounds: [[south, west], [north, east]].
+func (b BBox) LatLngs() [2][2]float64 {
+	return [2][2]float64{{b.MinLat, b.MinLon}, {b.MaxLat, b.MaxLon}}
+}
+
+// GeometryBounds returns the bounding box of a GeoJSON coordinates array of
+// any nesting depth (Point through MultiPolygon).
+func GeometryBounds(coordinates json.RawMessage) (BBox, bool) {
+	var v interface{}
+	if err := json.Unmarshal(coordinates, &v); err != nil {
+		return BBox{}, false
+	}
+	b := Empty()
+	walkPositions(v, func(lon, lat float64) { b = b.Extend(lon, lat) })
+	return b, b.Valid()
+}
+
+func walkPositions(v interface{}, fn func(lon, lat float64)) {
+	arr, ok := v.([]interface{})
+	if !ok || len(arr) == 0 {
+		return
+	}
+	if lon, ok := arr[0].(float64); ok {
+		if len(arr) >= 2 {
+			if lat, ok := arr[1].(float64); ok {
+				fn(lon, lat)
+			}
+		}
+		return
+	}
+	for _, child := range arr {
+		walkPositions(child, fn)
+	}
+}
@@ -0,0 +1,50 @@
+package geo
+
+import "strings"
+
+// stateFIPS maps state and territory codes to their two-digit FIPS codes,
+// which SAME location codes are built from.
+var stateFIPS = map[string]string{
+	"AL": "01", "AK": "02", "AZ": "04", "AR": "05", "CA": "06", "CO": "08",
+	"CT": "09", "DE": "10", "DC": "11", "FL": "12", "GA": "13", "HI": "15",
+	"ID": "16", "IL": "17", "IN": "18", "IA": "19", "KS": "20", "KY": "21",
+	"LA": "22", "ME": "23", "MD": "24", "MA": "25", "MI": "26", "MN": "27",
+	"MS": "28", "MO": "29", "MT": "30", "NE": "31", "NV": "32", "NH": "33",
+	"NJ": "34", "NM": "35", "NY": "36", "NC": "37", "ND": "38", "OH": "39",
+	"OK": "40", "OR": "41", "PA": "42", "RI": "44", "SC": "45", "SD": "46",
+	"TN": "47", "TX": "48", "UT": "49", "VT": "50", "VA": "51", "WA": "53",
+	"WV": "54", "WI": "55", "WY": "56", "AS": "60", "GU": "66", "PR": "72",
+	"VI": "78",
+}
+
+// CountySAME converts a county UGC code such as ALC073 to its SAME code,
+// 001073. Zone codes (ALZ024) have no SAME equivalent.
+func CountySAME(ugc string) (string, bool) {
+	ugc = strings.ToUpper(ugc)
+	if len(ugc) != 6 || ugc[2] != 'C' {
+		return "", false
+	}
+	fips, ok := stateFIPS[ugc[:2]]
+	if !ok {
+		return "", false
+	}
+	return "0" + fips + ugc[3:], true
+}
+
+// NormalizeSAME accepts a five-digit county FIPS code or a six-digit SAME
+// code and returns the SAME form.
+func NormalizeSAME(code string) (string, bool) {
+	code = strings.TrimSpace(code)
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+	switch len(code) {
+	case 5:
+		return "0" + code, true
+	case 6:
+		return code, true
+	}
+	return "", false
+}
@@ -0,0 +1,121 @@
+package geo
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// square is a closed ring from (x, y) to (x+size, y+size).
+func square(x, y, size float64) [][]float64 {
+	return [][]float64{{x, y}, {x + size, y}, {x + size, y + size}, {x, y + size}, {x, y}}
+}
+
+func mustJSON(t *testing.T, v interface{}) json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestContainsPoint(t *testing.T) {
+	// A 10° square with a 2° hole, and a MultiPolygon of it and a
+	// separate square to the east.
+	withHole := [][][]float64{square(0, 0, 10), square(4, 4, 2)}
+	polygon := mustJSON(t, withHole)
+	multi := mustJSON(t, [][][][]float64{withHole, {square(20, 0, 5)}})
+
+	cases := []struct {
+		name     string
+		typ      string
+		coords   json.RawMessage
+		lon, lat float64
+		want     bool
+	}{
+		{"inside", "Polygon", polygon, 1, 1, true},
+		{"in the hole", "Polygon", polygon, 5, 5, false},
+		{"between hole and edge", "Polygon", polygon, 7, 5, true},
+		{"outside", "Polygon", polygon, 11, 5, false},
+		{"beyond a vertex's latitude", "Polygon", polygon, -1, 10, false},
+		{"first part", "MultiPolygon", multi, 2, 8, true},
+		{"hole of first part", "MultiPolygon", multi, 5.5, 4.5, false},
+		{"second part", "MultiPolygon", multi, 22, 2, true},
+		{"between the parts", "MultiPolygon", multi, 15, 2, false},
+		{"point geometry", "Point", mustJSON(t, []float64{1, 1}), 1, 1, false},
+		{"malformed", "Polygon", json.RawMessage(`{"rings":1}`), 1, 1, false},
+	}
+	for _, c := range cases {
+		if got := ContainsPoint(c.typ, c.coords, c.lon, c.lat); got != c.want {
+			t.Errorf("%s: ContainsPoint(%v, %v) = %v, want %v", c.name, c.lon, c.lat, got, c.want)
+		}
+	}
+}
+
+// TestSharedEdge checks that a point on the border of two adjacent
+// counties is inside exactly one of them, so it is neither missed nor
+// counted twice, and that their union covers it.
+func TestSharedEdge(t *testing.T) {
+	west, east := square(0, 0, 2), square(2, 0, 2)
+	for _, p := range [][2]float64{{2, 1}, {2, 0}, {2, 2}, {1, 2}, {3, 0}} {
+		in := 0
+		for _, ring := range [][][]float64{west, east} {
+			if RingContains(ring, p[0], p[1]) {
+				in++
+			}
+		}
+		onOuterEdge := p[1] == 0 || p[1] == 2
+		if in > 1 || (!onOuterEdge && in != 1) {
+			t.Errorf("%v is inside %d of the two squares", p, in)
+		}
+	}
+	both := mustJSON(t, [][][][]float64{{west}, {east}})
+	if !ContainsPoint("MultiPolygon", both, 2, 1) {
+		t.Error("shared border not covered by the MultiPolygon")
+	}
+	// An edge of a hole belongs to the hole or the polygon, not both.
+	withHole := [][][]float64{square(0, 0, 10), square(4, 4, 2)}
+	for _, p := range [][2]float64{{4, 5}, {6, 5}} {
+		if RingContains(withHole[1], p[0], p[1]) == polygonContains(withHole, p[0], p[1]) {
+			t.Errorf("%v on the hole's edge is in both or neither", p)
+		}
+	}
+}
+
+func TestGeometryBounds(t *testing.T) {
+	cases := []struct {
+		name   string
+		coords string
+		want   BBox
+		ok     bool
+	}{
+		{"point", `[-86.5, 34.7]`, BBox{-86.5, 34.7, -86.5, 34.7}, true},
+		{"polygon with hole", `[[[-87,34],[-86,34],[-86,35],[-87,35],[-87,34]],[[-86.6,34.4],[-86.4,34.4],[-86.4,34.6],[-86.6,34.4]]]`,
+			BBox{-87, 34, -86, 35}, true},
+		{"multipolygon", `[[[[-87,34],[-86,34],[-86,35],[-87,34]]],[[[-80,30],[-79,30],[-79,31],[-80,30]]]]`,
+			BBox{-87, 30, -79, 35}, true},
+		{"empty", `[]`, BBox{}, false},
+		{"malformed", `{"x":1}`, BBox{}, false},
+	}
+	for _, c := range cases {
+		got, ok := GeometryBounds(json.RawMessage(c.coords))
+		if ok != c.ok || (ok && got != c.want) {
+			t.Errorf("%s: GeometryBounds = %+v, %v; want %+v, %v", c.name, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestStateBounds(t *testing.T) {
+	al, ok := StateBounds("AL")
+	if !ok || !al.Contains(-86.8, 33.5) {
+		t.Fatalf("AL bounds %+v, %v don't contain Birmingham", al, ok)
+	}
+	if b, ok := StateBounds("alc073"); !ok || b != al {
+		t.Errorf("UGC code not matched on its state prefix: %+v, %v", b, ok)
+	}
+	for _, code := range []string{"", "A", "XX"} {
+		if _, ok := StateBounds(code); ok {
+			t.Errorf("StateBounds(%q) succeeded", code)
+		}
+	}
+}
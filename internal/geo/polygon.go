@@ -0,0 +1,66 @@
+package geo
+
+import "encoding/json"
+
+// ContainsPoint reports whether a GeoJSON Polygon or MultiPolygon contains
+// the point. Holes are honoured. A point on an edge may fall either way, but
+// one on an edge shared by two polygons falls in exactly one of them.
+// Other geometry types never contain a point.
+func ContainsPoint(geometryType string, coordinates json.RawMessage, lon, lat float64) bool {
+	for _, rings := range Polygons(geometryType, coordinates) {
+		if polygonContains(rings, lon, lat) {
+			return true
+		}
+	}
+	return false
+}
+
+// Polygons decodes a GeoJSON Polygon or MultiPolygon into its polygons,
+// each an outer ring followed by any holes, with positions as [lon, lat].
+// Other geometry types and malformed coordinates yield nil.
+func Polygons(geometryType string, coordinates json.RawMessage) [][][][]float64 {
+	switch geometryType {
+	case "Polygon":
+		var rings [][][]float64
+		if err := json.Unmarshal(coordinates, &rings); err != nil {
+			return nil
+		}
+		return [][][][]float64{rings}
+	case "MultiPolygon":
+		var polygons [][][][]float64
+		if err := json.Unmarshal(coordinates, &polygons); err != nil {
+			return nil
+		}
+		return polygons
+	}
+	return nil
+}
+
+// polygonContains tests the outer ring, then rejects points inside a hole.
+func polygonContains(rings [][][]float64, lon, lat float64) bool {
+	if len(rings) == 0 || !RingContains(rings[0], lon, lat) {
+		return false
+	}
+	for _, hole := range rings[1:] {
+		if RingContains(hole, lon, lat) {
+			return false
+		}
+	}
+	return true
+}
+
+// RingContains is the even-odd ray casting test.
+func RingContains(ring [][]float64, lon, lat float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		if len(ring[i]) < 2 || len(ring[j]) < 2 {
+			continue
+		}
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
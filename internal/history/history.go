@@ -0,0 +1,306 @@
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+	"github.com/Zachdehooge/warnings-dashboard/internal/geo"
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS alerts (
+	id         TEXT PRIMARY KEY,
+	type       TEXT NOT NULL,
+	severity   TEXT NOT NULL,
+	area       TEXT NOT NULL,
+	sent       TEXT NOT NULL,
+	expires    TEXT NOT NULL,
+	first_seen INTEGER NOT NULL,
+	last_seen  INTEGER NOT NULL,
+	data       TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS alerts_seen ON alerts (first_seen, last_seen);
+CREATE TABLE IF NOT EXISTS alert_ugc (
+	alert_id TEXT NOT NULL,
+	ugc      TEXT NOT NULL,
+	PRIMARY KEY (alert_id, ugc)
+);
+CREATE INDEX IF NOT EXISTS alert_ugc_code ON alert_ugc (ugc);
+CREATE TABLE IF NOT EXISTS mcds (
+	id         TEXT PRIMARY KEY,
+	name       TEXT NOT NULL,
+	first_seen INTEGER NOT NULL,
+	last_seen  INTEGER NOT NULL,
+	data       TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS mcds_seen ON mcds (first_seen, last_seen);
+`
+
+// migrateMCDKeys prefixes MCDs stored by number alone with the year they
+// were first seen, the key RecordMCDs uses since SPC numbers restart every
+// year.
+const migrateMCDKeys = `UPDATE mcds SET id = strftime('%Y', first_seen, 'unixepoch') || ' ' || id WHERE id LIKE 'MCD %'`
+
+// Store is an SQLite database of every alert and mesoscale discussion the
+// poller has seen. It uses WAL mode so the history command can query it
+// while a dashboard is writing.
+type Store struct {
+	db *sql.DB
+}
+
+// MCD is a mesoscale discussion as stored in the history.
+type MCD struct {
+	ID       string            `json:"id"`
+	Name     string            `json:"name"`
+	FullText string            `json:"fullText"`
+	Geometry *fetcher.Geometry `json:"geometry"`
+	// Issued is when SPC issued the discussion, if known. Its year tells
+	// apart discussions with the same number from different years.
+	Issued time.Time `json:"issued,omitzero"`
+}
+
+// key is the discussion's primary key: the year it was issued, or else
+// seen, and its ID, e.g. "2025 MCD 0001".
+func (m MCD) key(seen time.Time) string {
+	year := seen.UTC().Year()
+	if !m.Issued.IsZero() {
+		year = m.Issued.UTC().Year()
+	}
+	return fmt.Sprintf("%d %s", year, m.ID)
+}
+
+// Alert is a stored alert with the span of poll cycles it was seen in.
+type Alert struct {
+	FirstSeen time.Time       `json:"firstSeen"`
+	LastSeen  time.Time       `json:"lastSeen"`
+	Alert     fetcher.Warning `json:"alert"`
+}
+
+// MCDRecord is a stored mesoscale discussion with the span it was seen in.
+type MCDRecord struct {
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	MCD       MCD       `json:"mcd"`
+}
+
+// Query selects stored records. Zero values match everything; a record
+// matches the time range if it was seen at any point inside it.
+type Query struct {
+	From, To time.Time
+	// Types are event names such as "Tornado Warning", matched case-insensitively.
+	Types []string
+	// States are two-letter codes matched against the alert's UGC prefixes.
+	States []string
+	UGC    []string
+}
+
+// Open opens or creates the history database at path.
+func Open(path string) (*Store, error) {
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	for _, stmt := range []string{schema, migrateMCDKeys} {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to initialise history: %w", err)
+		}
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// RecordAlerts stores the alerts seen in one poll cycle. New alerts get
+// seen as their first-seen time; known alerts have their last-seen time and
+// stored copy updated.
+func (s *Store) RecordAlerts(ws []fetcher.Warning, seen time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to record alerts: %w", err)
+	}
+	defer tx.Rollback()
+
+	at := seen.Unix()
+	for _, w := range ws {
+		data, err := json.Marshal(w)
+		if err != nil {
+			return fmt.Errorf("failed to encode alert %s: %w", w.ID, err)
+		}
+		_, err = tx.Exec(`
+			INSERT INTO alerts (id, type, severity, area, sent, expires, first_seen, last_seen, data)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET
+				expires = excluded.expires,
+				last_seen = max(alerts.last_seen, excluded.last_seen),
+				data = excluded.data`,
+			w.ID, w.Type, w.Severity, w.Area, w.Time, w.ExpiresTime, at, at, string(data))
+		if err != nil {
+			return fmt.Errorf("failed to record alert %s: %w", w.ID, err)
+		}
+		for _, code := range w.UGC {
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO alert_ugc (alert_id, ugc) VALUES (?, ?)`, w.ID, code); err != nil {
+				return fmt.Errorf("failed to record alert %s: %w", w.ID, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// RecordMCDs stores the mesoscale discussions seen in one poll cycle,
+// keyed by the year they were issued and their number.
+func (s *Store) RecordMCDs(mcds []MCD, seen time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to record MCDs: %w", err)
+	}
+	defer tx.Rollback()
+
+	at := seen.Unix()
+	for _, m := range mcds {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return fmt.Errorf("failed to encode MCD %s: %w", m.ID, err)
+		}
+		_, err = tx.Exec(`
+			INSERT INTO mcds (id, name, first_seen, last_seen, data)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET
+				last_seen = max(mcds.last_seen, excluded.last_seen),
+				data = excluded.data`,
+			m.key(seen), m.Name, at, at, string(data))
+		if err != nil {
+			return fmt.Errorf("failed to record MCD %s: %w", m.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Alerts returns the stored alerts matching q, oldest first.
+func (s *Store) Alerts(q Query) ([]Alert, error) {
+	where, args := q.seenClause("a")
+	if len(q.Types) > 0 {
+		where = append(where, "lower(a.type) IN ("+placeholders(len(q.Types))+")")
+		for _, t := range q.Types {
+			args = append(args, strings.ToLower(t))
+		}
+	}
+	if len(q.States) > 0 {
+		where = append(where, "a.id IN (SELECT alert_id FROM alert_ugc WHERE substr(ugc, 1, 2) IN ("+placeholders(len(q.States))+"))")
+		for _, st := range q.States {
+			args = append(args, strings.ToUpper(st))
+		}
+	}
+	if len(q.UGC) > 0 {
+		where = append(where, "a.id IN (SELECT alert_id FROM alert_ugc WHERE ugc IN ("+placeholders(len(q.UGC))+"))")
+		for _, u := range q.UGC {
+			args = append(args, strings.ToUpper(u))
+		}
+	}
+
+	query := "SELECT a.first_seen, a.last_seen, a.data FROM alerts a"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY a.first_seen, a.id"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Alert
+	for rows.Next() {
+		var first, last int64
+		var data string
+		if err := rows.Scan(&first, &last, &data); err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		a := Alert{FirstSeen: time.Unix(first, 0).UTC(), LastSeen: time.Unix(last, 0).UTC()}
+		if err := json.Unmarshal([]byte(data), &a.Alert); err != nil {
+			return nil, fmt.Errorf("failed to decode stored alert: %w", err)
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// MCDs returns the stored mesoscale discussions matching q's time range,
+// oldest first. MCDs carry no UGC codes, so States matches discussions
+// whose polygon overlaps the state's extent and Types and UGC are ignored.
+func (s *Store) MCDs(q Query) ([]MCDRecord, error) {
+	where, args := q.seenClause("m")
+	query := "SELECT m.first_seen, m.last_seen, m.data FROM mcds m"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY m.first_seen, m.id"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	var out []MCDRecord
+	for rows.Next() {
+		var first, last int64
+		var data string
+		if err := rows.Scan(&first, &last, &data); err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		r := MCDRecord{FirstSeen: time.Unix(first, 0).UTC(), LastSeen: time.Unix(last, 0).UTC()}
+		if err := json.Unmarshal([]byte(data), &r.MCD); err != nil {
+			return nil, fmt.Errorf("failed to decode stored MCD: %w", err)
+		}
+		if len(q.States) > 0 && !inStates(r.MCD.Geometry, q.States) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func (q Query) seenClause(table string) ([]string, []interface{}) {
+	var where []string
+	var args []interface{}
+	if !q.From.IsZero() {
+		where = append(where, table+".last_seen >= ?")
+		args = append(args, q.From.Unix())
+	}
+	if !q.To.IsZero() {
+		where = append(where, table+".first_seen <= ?")
+		args = append(args, q.To.Unix())
+	}
+	return where, args
+}
+
+func inStates(g *fetcher.Geometry, states []string) bool {
+	if g == nil {
+		return false
+	}
+	b, ok := geo.GeometryBounds(g.Coordinates)
+	if !ok {
+		return false
+	}
+	for _, st := range states {
+		if sb, ok := geo.StateBounds(st); ok && sb.Intersects(b) {
+			return true
+		}
+	}
+	return false
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,78 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMCDNumbersRestartEachYear(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	lastYear := time.Date(2025, 3, 2, 20, 0, 0, 0, time.UTC)
+	thisYear := time.Date(2026, 1, 3, 20, 0, 0, 0, time.UTC)
+	old := MCD{ID: "MCD 0001", Name: "MD 0001", FullText: "last year", Issued: lastYear}
+	if err := s.RecordMCDs([]MCD{old}, lastYear); err != nil {
+		t.Fatal(err)
+	}
+	// Issued late on New Year's Eve, still listed after midnight.
+	late := MCD{ID: "MCD 2500", Name: "MD 2500", Issued: time.Date(2025, 12, 31, 23, 50, 0, 0, time.UTC)}
+	cur := MCD{ID: "MCD 0001", Name: "MD 0001", FullText: "this year", Issued: thisYear}
+	if err := s.RecordMCDs([]MCD{late, cur}, thisYear); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.MCDs(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d MCDs, want 3", len(got))
+	}
+	if got[0].MCD.FullText != "last year" || !got[0].FirstSeen.Equal(lastYear) || !got[0].LastSeen.Equal(lastYear) {
+		t.Errorf("last year's MCD 0001 changed: %+v", got[0])
+	}
+	// Ordered by first seen, then key, so the late one comes first.
+	if got[1].MCD.ID != "MCD 2500" || got[2].MCD.FullText != "this year" || !got[2].FirstSeen.Equal(thisYear) {
+		t.Errorf("this year's MCDs: %+v, %+v", got[1], got[2])
+	}
+	var key string
+	if err := s.db.QueryRow(`SELECT id FROM mcds WHERE name = 'MD 2500'`).Scan(&key); err != nil || key != "2025 MCD 2500" {
+		t.Errorf("MCD 2500 stored as %q (%v), want the year it was issued", key, err)
+	}
+}
+
+func TestOpenMigratesMCDKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := s.db.Exec(`INSERT INTO mcds (id, name, first_seen, last_seen, data) VALUES (?, ?, ?, ?, ?)`,
+		"MCD 0900", "MD 0900", seen.Unix(), seen.Unix(), `{"id":"MCD 0900","name":"MD 0900"}`); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	if s, err = Open(path); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	// Seen again without an issue time: the same row, not a second one.
+	if err := s.RecordMCDs([]MCD{{ID: "MCD 0900", Name: "MD 0900"}}, seen.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	var id string
+	var n int
+	if err := s.db.QueryRow(`SELECT min(id), count(*) FROM mcds`).Scan(&id, &n); err != nil {
+		t.Fatal(err)
+	}
+	if id != "2025 MCD 0900" || n != 1 {
+		t.Errorf("got %d rows, first %q; want 1, %q", n, id, "2025 MCD 0900")
+	}
+}
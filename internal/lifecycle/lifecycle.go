@@ -0,0 +1,180 @@
+package lifecycle
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+)
+
+// Kind classifies what happened to an alert between two poll cycles.
+type Kind string
+
+const (
+	New       Kind = "new"
+	Continued Kind = "continued"
+	Updated   Kind = "updated"
+	Extended  Kind = "extended"
+	Cancelled Kind = "cancelled"
+	Expired   Kind = "expired"
+)
+
+// retention is how long events stay in the payload, so clients that poll
+// less often than the server still see every change.
+const retention = 10 * time.Minute
+
+// Event records a lifecycle change. Continued alerts produce no event.
+type Event struct {
+	Kind Kind   `json:"kind"`
+	ID   string `json:"id"`
+	// PreviousID is the alert an update or extension replaced, and
+	// PreviousTier that alert's tier.
+	PreviousID   string `json:"previousId,omitempty"`
+	PreviousTier string `json:"previousTier,omitempty"`
+	Type         string `json:"type"`
+	Area         string `json:"area"`
+	Tier         string `json:"tier"`
+	ExpiresTime  string `json:"expiresTime"`
+	// Early is set on cancellations that ended an alert before it expired.
+	Early bool   `json:"early,omitempty"`
+	At    string `json:"at"`
+}
+
+// Tracker remembers the previous cycle's alerts so each new cycle can be
+// classified against it. It is not safe for concurrent use.
+type Tracker struct {
+	prev map[string]fetcher.Warning
+	// cancels are the Cancel messages in the previous cycle. They are kept
+	// apart from prev: a cancellation is reported once when it appears, not
+	// again as an alert ending when it leaves the feed.
+	cancels map[string]bool
+	events  []Event
+}
+
+// NewTracker returns a tracker with no history. The first Diff establishes
+// the baseline and reports no events.
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+// Diff classifies the current alerts against the previous cycle, sets each
+// alert's Lifecycle field, and returns the events of the last few minutes,
+// newest first.
+func (t *Tracker) Diff(current []fetcher.Warning, now time.Time) []Event {
+	at := now.UTC().Format(time.RFC3339)
+	baseline := t.prev == nil
+	var fresh []Event
+
+	curByID := make(map[string]bool, len(current))
+	for _, w := range current {
+		curByID[w.ID] = true
+	}
+
+	// superseded holds previous alerts that a current alert updates or cancels.
+	superseded := make(map[string]bool)
+	for i := range current {
+		w := &current[i]
+		if baseline {
+			w.Lifecycle = string(New)
+			continue
+		}
+		if _, ok := t.prev[w.ID]; ok || t.cancels[w.ID] {
+			w.Lifecycle = string(Continued)
+			continue
+		}
+
+		prev, found := t.referenced(*w)
+		if found {
+			superseded[prev.ID] = true
+		}
+
+		kind := New
+		switch {
+		case w.MessageType == "Cancel":
+			kind = Cancelled
+		case found && later(w.ExpiresTime, prev.ExpiresTime):
+			kind = Extended
+		case found || w.MessageType == "Update":
+			kind = Updated
+		}
+		w.Lifecycle = string(kind)
+
+		e := newEvent(kind, *w, at)
+		if found {
+			e.PreviousID, e.PreviousTier = prev.ID, prev.Tier
+		}
+		if kind == Cancelled {
+			e.Early = true
+		}
+		fresh = append(fresh, e)
+	}
+
+	if !baseline {
+		for id, p := range t.prev {
+			if curByID[id] || superseded[id] {
+				continue
+			}
+			e := newEvent(Expired, p, at)
+			if exp, err := time.Parse(time.RFC3339, p.ExpiresTime); err == nil && exp.After(now) {
+				e.Kind, e.Early = Cancelled, true
+			}
+			fresh = append(fresh, e)
+		}
+	}
+
+	t.prev = make(map[string]fetcher.Warning, len(current))
+	t.cancels = make(map[string]bool)
+	for _, w := range current {
+		if w.MessageType == "Cancel" {
+			t.cancels[w.ID] = true
+			continue
+		}
+		t.prev[w.ID] = w
+	}
+
+	sort.SliceStable(fresh, func(i, j int) bool { return fresh[i].ID < fresh[j].ID })
+	t.events = append(fresh, t.events...)
+	cutoff := now.Add(-retention)
+	kept := t.events[:0]
+	for _, e := range t.events {
+		if ts, err := time.Parse(time.RFC3339, e.At); err == nil && ts.Before(cutoff) {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	t.events = kept
+
+	out := make([]Event, len(t.events))
+	copy(out, t.events)
+	return out
+}
+
+// referenced returns the previous-cycle alert that w names in its CAP
+// references, if any.
+func (t *Tracker) referenced(w fetcher.Warning) (fetcher.Warning, bool) {
+	for i := len(w.References) - 1; i >= 0; i-- {
+		if p, ok := t.prev[w.References[i].Identifier]; ok {
+			return p, true
+		}
+	}
+	return fetcher.Warning{}, false
+}
+
+func newEvent(kind Kind, w fetcher.Warning, at string) Event {
+	return Event{
+		Kind:        kind,
+		ID:          w.ID,
+		Type:        w.Type,
+		Area:        w.Area,
+		Tier:        w.Tier,
+		ExpiresTime: w.ExpiresTime,
+		At:          at,
+	}
+}
+
+// later reports whether timestamp a is after b. Unparseable values are never later.
+func later(a, b string) bool {
+	ta, errA := time.Parse(time.RFC3339, a)
+	tb, errB := time.Parse(time.RFC3339, b)
+	return errA == nil && errB == nil && ta.After(tb)
+}
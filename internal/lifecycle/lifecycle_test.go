@@ -0,0 +1,123 @@
+package lifecycle
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+)
+
+var t0 = time.Date(2026, 5, 1, 18, 0, 0, 0, time.UTC)
+
+// alert is a warning expiring the given time after t0, replacing refs.
+func alert(id, msgType string, expires time.Duration, refs ...string) fetcher.Warning {
+	w := fetcher.Warning{
+		ID:          id,
+		Type:        "Severe Thunderstorm Warning",
+		MessageType: msgType,
+		ExpiresTime: t0.Add(expires).Format(time.RFC3339),
+	}
+	for _, r := range refs {
+		w.References = append(w.References, fetcher.Reference{Identifier: r})
+	}
+	return w
+}
+
+func TestTrackerDiff(t *testing.T) {
+	steps := []struct {
+		name    string
+		at      time.Duration
+		current []fetcher.Warning
+		// lifecycle is each current alert's Lifecycle, and events the
+		// events Diff returns, written kind:id[<previous][!] with ! for
+		// early.
+		lifecycle []Kind
+		events    []string
+	}{
+		{
+			name: "baseline",
+			current: []fetcher.Warning{
+				alert("A", "Alert", 30*time.Minute),
+				alert("B", "Alert", 20*time.Minute),
+				alert("C", "Alert", 5*time.Hour),
+				alert("D", "Alert", 10*time.Minute),
+			},
+			lifecycle: []Kind{New, New, New, New},
+		},
+		{
+			name: "update, extension, early end and new alert",
+			at:   5 * time.Minute,
+			current: []fetcher.Warning{
+				alert("A", "Alert", 30*time.Minute),
+				alert("B2", "Update", 20*time.Minute, "B0", "B"),
+				alert("C2", "Update", 6*time.Hour, "C"),
+				alert("E", "Alert", 11*time.Minute),
+			},
+			lifecycle: []Kind{Continued, Updated, Extended, New},
+			events:    []string{"updated:B2<B", "extended:C2<C", "cancelled:D!", "new:E"},
+		},
+		{
+			name: "cancel message",
+			at:   10 * time.Minute,
+			current: []fetcher.Warning{
+				alert("AX", "Cancel", 30*time.Minute, "A"),
+				alert("B2", "Update", 20*time.Minute, "B0", "B"),
+				alert("C2", "Update", 6*time.Hour, "C"),
+				alert("E", "Alert", 11*time.Minute),
+			},
+			lifecycle: []Kind{Cancelled, Continued, Continued, Continued},
+			events:    []string{"cancelled:AX<A!", "updated:B2<B", "extended:C2<C", "cancelled:D!", "new:E"},
+		},
+		{
+			name: "cancel message leaves the feed, alert expires",
+			at:   12 * time.Minute,
+			current: []fetcher.Warning{
+				alert("B2", "Update", 20*time.Minute, "B0", "B"),
+				alert("C2", "Update", 6*time.Hour, "C"),
+			},
+			lifecycle: []Kind{Continued, Continued},
+			events:    []string{"expired:E", "cancelled:AX<A!", "updated:B2<B", "extended:C2<C", "cancelled:D!", "new:E"},
+		},
+		{
+			name: "older events drop out, update of an unknown alert",
+			at:   16 * time.Minute,
+			current: []fetcher.Warning{
+				alert("B2", "Update", 20*time.Minute, "B0", "B"),
+				alert("C2", "Update", 6*time.Hour, "C"),
+				alert("F", "Update", time.Hour, "gone"),
+			},
+			lifecycle: []Kind{Continued, Continued, Updated},
+			events:    []string{"updated:F", "expired:E", "cancelled:AX<A!"},
+		},
+	}
+
+	tr := NewTracker()
+	for _, s := range steps {
+		events := tr.Diff(s.current, t0.Add(s.at))
+
+		var lifecycle []Kind
+		for _, w := range s.current {
+			lifecycle = append(lifecycle, Kind(w.Lifecycle))
+		}
+		if !reflect.DeepEqual(lifecycle, s.lifecycle) {
+			t.Errorf("%s: lifecycle %v, want %v", s.name, lifecycle, s.lifecycle)
+		}
+
+		var got []string
+		for _, e := range events {
+			s := fmt.Sprintf("%s:%s", e.Kind, e.ID)
+			if e.PreviousID != "" {
+				s += "<" + e.PreviousID
+			}
+			if e.Early {
+				s += "!"
+			}
+			got = append(got, s)
+		}
+		if !reflect.DeepEqual(got, s.events) {
+			t.Errorf("%s: events %q, want %q", s.name, got, s.events)
+		}
+	}
+}
@@ -0,0 +1,373 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+	"github.com/Zachdehooge/warnings-dashboard/internal/generator"
+	"github.com/Zachdehooge/warnings-dashboard/internal/rules"
+)
+
+// batchWindow is how long an email target collects alert changes before
+// sending them as one email, so an outbreak does not send one per warning.
+const batchWindow = 10 * time.Second
+
+const defaultEmailText = `{{if .Messages -}}
+{{range .Messages}}* {{.Title}}
+{{with .Alert.Headline}}  {{.}}
+{{end}}{{with .Alert.ExpiresTime}}  Expires: {{.}}
+{{end}}{{with .Alert.Instruction}}  {{.}}
+{{end}}
+{{end}}
+{{- else -}}
+{{.Total}} active alerts at {{.At.Format "Jan 2, 2006 3:04 PM MST"}}
+
+{{range .Counts}}{{printf "%4d" .Count}}  {{.Type}}
+{{end}}
+{{- end}}`
+
+const defaultEmailHTML = `<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:20px;background:#000;color:#fff;font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,Helvetica,Arial,sans-serif;">
+<div style="max-width:640px;margin:0 auto;">
+<h1 style="font-size:20px;margin:0 0 16px;">{{.Subject}}</h1>
+{{- if .Messages}}
+<ul style="padding-left:20px;margin:0 0 20px;">
+{{range .Messages}}<li style="margin-bottom:4px;">{{.Title}}</li>
+{{end}}</ul>
+{{cards .Alerts}}
+{{- else}}
+<p style="color:#888;">As of {{.At.Format "Jan 2, 2006 3:04 PM MST"}}</p>
+<table cellpadding="6" cellspacing="0" style="border-collapse:collapse;">
+{{range .Counts}}<tr><td style="border-bottom:1px solid #333;">{{.Type}}</td><td align="right" style="border-bottom:1px solid #333;font-weight:700;">{{.Count}}</td></tr>
+{{end}}</table>
+{{- end}}
+</div>
+</body>
+</html>`
+
+// EmailConfig describes one email target.
+type EmailConfig struct {
+	Name string `yaml:"name"`
+	// Server is the SMTP server as host:port, e.g. localhost:1025 for a
+	// local sink. STARTTLS is used when the server offers it.
+	Server   string   `yaml:"server"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+	// On lists the kinds of change to send; DefaultKinds when empty.
+	On []string `yaml:"on"`
+	// Rules selects which alerts to send and count in digests; every alert
+	// when empty.
+	Rules *rules.Set `yaml:"rules"`
+	// Digest is how often to send a summary of active alert counts, e.g.
+	// "1h"; no digests are sent when zero.
+	Digest time.Duration `yaml:"digest"`
+	// TextTemplate and HTMLTemplate are paths of text/template and
+	// html/template files rendered with EmailData. The built-in templates
+	// are used when empty.
+	TextTemplate string `yaml:"textTemplate"`
+	HTMLTemplate string `yaml:"htmlTemplate"`
+	// Retries is how many times a failed send is retried; zero means the
+	// default and a negative value disables retries.
+	Retries int `yaml:"retries"`
+}
+
+// EmailData is what email templates render.
+type EmailData struct {
+	Subject string
+	// Messages are the alert changes the email reports, empty for a digest.
+	Messages []Message
+	// Alerts are the alerts the messages are about.
+	Alerts []fetcher.Warning
+	// Counts and Total summarise the active alerts in a digest.
+	Counts []generator.TypeCount
+	Total  int
+	At     time.Time
+}
+
+// Email sends alert changes and optional digests over SMTP. Changes that
+// arrive together are batched into one email.
+type Email struct {
+	cfg   EmailConfig
+	kinds map[string]bool
+	text  *template.Template
+	html  *htmltemplate.Template
+	queue chan Message
+	done  chan struct{}
+
+	mu     sync.Mutex
+	active []fetcher.Warning
+	seen   bool
+}
+
+// NewEmail validates cfg, loads its templates and starts the target's
+// sending worker.
+func NewEmail(cfg EmailConfig) (*Email, error) {
+	if cfg.Server == "" {
+		return nil, fmt.Errorf("email %q: server is required", cfg.Name)
+	}
+	if cfg.Name == "" {
+		cfg.Name = cfg.Server
+	}
+	if _, _, err := net.SplitHostPort(cfg.Server); err != nil {
+		return nil, fmt.Errorf("email %q: server must be host:port: %w", cfg.Name, err)
+	}
+	if cfg.From == "" || len(cfg.To) == 0 {
+		return nil, fmt.Errorf("email %q: from and to are required", cfg.Name)
+	}
+	if cfg.Rules != nil {
+		if err := cfg.Rules.Validate(); err != nil {
+			return nil, fmt.Errorf("email %q: %w", cfg.Name, err)
+		}
+	}
+	if cfg.Digest < 0 {
+		return nil, fmt.Errorf("email %q: negative digest interval", cfg.Name)
+	}
+	if cfg.Retries < 0 {
+		cfg.Retries = 0
+	} else if cfg.Retries == 0 {
+		cfg.Retries = defaultRetries
+	}
+	kinds, err := parseKinds(cfg.On)
+	if err != nil {
+		return nil, fmt.Errorf("email %q: %w", cfg.Name, err)
+	}
+
+	textSrc, err := readTemplate(cfg.TextTemplate, defaultEmailText)
+	if err != nil {
+		return nil, fmt.Errorf("email %q: %w", cfg.Name, err)
+	}
+	text, err := template.New("text").Parse(textSrc)
+	if err != nil {
+		return nil, fmt.Errorf("email %q: invalid text template: %w", cfg.Name, err)
+	}
+	htmlSrc, err := readTemplate(cfg.HTMLTemplate, defaultEmailHTML)
+	if err != nil {
+		return nil, fmt.Errorf("email %q: %w", cfg.Name, err)
+	}
+	html, err := htmltemplate.New("html").Funcs(htmltemplate.FuncMap{
+		"cards": generator.EmailCards,
+	}).Parse(htmlSrc)
+	if err != nil {
+		return nil, fmt.Errorf("email %q: invalid HTML template: %w", cfg.Name, err)
+	}
+
+	e := &Email{
+		cfg:   cfg,
+		kinds: kinds,
+		text:  text,
+		html:  html,
+		queue: make(chan Message, queueSize),
+		done:  make(chan struct{}),
+	}
+	go e.run()
+	return e, nil
+}
+
+func readTemplate(path, fallback string) (string, error) {
+	if path == "" {
+		return fallback, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template: %w", err)
+	}
+	return string(data), nil
+}
+
+// Send queues m if the target wants it. A full queue drops the message
+// rather than stall the poller.
+func (e *Email) Send(m Message) {
+	if !m.Test && (!e.kinds[m.Kind] || !e.cfg.Rules.Allow(m.Alert.RuleAlert())) {
+		return
+	}
+	select {
+	case e.queue <- m:
+	default:
+		log.Printf("[notify] email %s: queue full, dropped %s", e.cfg.Name, m.Key)
+	}
+}
+
+// Observe keeps the active alerts the target's rules allow for the next
+// digest.
+func (e *Email) Observe(warnings []fetcher.Warning) {
+	var active []fetcher.Warning
+	for _, w := range warnings {
+		if e.cfg.Rules.Allow(w.RuleAlert()) {
+			active = append(active, w)
+		}
+	}
+	e.mu.Lock()
+	e.active, e.seen = active, true
+	e.mu.Unlock()
+}
+
+// Close stops the target, sending any batched changes first.
+func (e *Email) Close() {
+	close(e.queue)
+	<-e.done
+}
+
+func (e *Email) run() {
+	defer close(e.done)
+
+	var digest <-chan time.Time
+	if e.cfg.Digest > 0 {
+		t := time.NewTicker(e.cfg.Digest)
+		defer t.Stop()
+		digest = t.C
+	}
+
+	var (
+		batch []Message
+		flush <-chan time.Time
+	)
+	for {
+		select {
+		case m, ok := <-e.queue:
+			if !ok {
+				if len(batch) > 0 {
+					e.sendChanges(batch)
+				}
+				return
+			}
+			if len(batch) == 0 {
+				flush = time.After(batchWindow)
+			}
+			batch = append(batch, m)
+		case <-flush:
+			e.sendChanges(batch)
+			batch, flush = nil, nil
+		case <-digest:
+			e.sendDigest()
+		}
+	}
+}
+
+func (e *Email) sendChanges(batch []Message) {
+	d := EmailData{Messages: batch, At: time.Now()}
+	seen := map[string]bool{}
+	for _, m := range batch {
+		if !seen[m.Alert.ID] {
+			seen[m.Alert.ID] = true
+			d.Alerts = append(d.Alerts, m.Alert)
+		}
+	}
+	d.Subject = batch[0].Title
+	if len(batch) > 1 {
+		d.Subject = fmt.Sprintf("%s (+%d more)", batch[0].Title, len(batch)-1)
+	}
+	e.send(d, batch[0].Key)
+}
+
+// sendDigest sends the active alert counts. Nothing is sent before the
+// first poll cycle or while no alerts are active.
+func (e *Email) sendDigest() {
+	e.mu.Lock()
+	active, seen := e.active, e.seen
+	e.mu.Unlock()
+	if !seen || len(active) == 0 {
+		return
+	}
+	d := EmailData{
+		Alerts: active,
+		Counts: generator.WarningTypeCounts(active),
+		Total:  len(active),
+		At:     time.Now(),
+	}
+	d.Subject = fmt.Sprintf("Alert digest: %d active", d.Total)
+	e.send(d, "digest:"+d.At.UTC().Format(time.RFC3339))
+}
+
+// send renders d and delivers it, retrying temporary failures.
+func (e *Email) send(d EmailData, key string) {
+	msg, err := e.compose(d, key)
+	if err != nil {
+		log.Printf("[notify] email %s: %v", e.cfg.Name, err)
+		return
+	}
+	host, _, _ := net.SplitHostPort(e.cfg.Server)
+	var auth smtp.Auth
+	if e.cfg.Username != "" {
+		auth = smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, host)
+	}
+	retry("email "+e.cfg.Name, key, e.cfg.Retries, func() (bool, time.Duration, error) {
+		err := smtp.SendMail(e.cfg.Server, auth, e.cfg.From, e.cfg.To, msg)
+		// Only 4xx replies are temporary; anything else the server said
+		// will not change on a retry. Connection errors are retried.
+		var tpErr *textproto.Error
+		if errors.As(err, &tpErr) {
+			return tpErr.Code >= 400 && tpErr.Code < 500, 0, err
+		}
+		return err != nil, 0, err
+	})
+}
+
+// compose builds a multipart/alternative message with text and HTML parts.
+// The Message-ID is derived from key, so a resent email can be recognised.
+func (e *Email) compose(d EmailData, key string) ([]byte, error) {
+	var text, html bytes.Buffer
+	if err := e.text.Execute(&text, d); err != nil {
+		return nil, fmt.Errorf("text template failed: %w", err)
+	}
+	if err := e.html.Execute(&html, d); err != nil {
+		return nil, fmt.Errorf("HTML template failed: %w", err)
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	header := func(k, v string) { fmt.Fprintf(&buf, "%s: %s\r\n", k, v) }
+	header("From", e.cfg.From)
+	header("To", strings.Join(e.cfg.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", d.Subject))
+	header("Date", d.At.Format(time.RFC1123Z))
+	header("Message-ID", fmt.Sprintf("<%x@warnings-dashboard>", sha256.Sum256([]byte(key))))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct {
+		contentType string
+		body        []byte
+	}{
+		{"text/plain; charset=utf-8", text.Bytes()},
+		{"text/html; charset=utf-8", html.Bytes()},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write(part.body); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
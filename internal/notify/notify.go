@@ -0,0 +1,238 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+	"github.com/Zachdehooge/warnings-dashboard/internal/lifecycle"
+)
+
+// Kinds of alert state change a target can subscribe to.
+const (
+	KindNew       = "new"
+	KindUpdated   = "updated"
+	KindExtended  = "extended"
+	KindUpgraded  = "upgraded"
+	KindCancelled = "cancelled"
+	KindExpired   = "expired"
+)
+
+// DefaultKinds are the changes a target hears about when it lists none.
+var DefaultKinds = []string{KindNew, KindUpgraded, KindCancelled}
+
+const (
+	// seenRetention is how long a state change is remembered for dedupe.
+	// The lifecycle tracker repeats events for ten minutes; this comfortably
+	// outlasts that and any restart of an upstream feed.
+	seenRetention = 24 * time.Hour
+	// knownRetention is how long an alert that left the feed is kept, so a
+	// late cancellation can still describe it.
+	knownRetention = time.Hour
+	// maxBackoff caps the wait between delivery retries.
+	maxBackoff = 2 * time.Minute
+)
+
+// Config is the notify: section of the configuration file.
+type Config struct {
+	Webhooks []WebhookConfig `yaml:"webhooks"`
+	Email    []EmailConfig   `yaml:"email"`
+}
+
+// parseKinds returns the set of kinds in on, or DefaultKinds when on is
+// empty.
+func parseKinds(on []string) (map[string]bool, error) {
+	if len(on) == 0 {
+		on = DefaultKinds
+	}
+	kinds := map[string]bool{}
+	for _, k := range on {
+		switch k {
+		case KindNew, KindUpdated, KindExtended, KindUpgraded, KindCancelled, KindExpired:
+			kinds[k] = true
+		default:
+			return nil, fmt.Errorf("unknown kind %q", k)
+		}
+	}
+	return kinds, nil
+}
+
+// Message is one alert state change. It is the JSON body of plain webhooks
+// and the data that message templates render.
+type Message struct {
+	// Key identifies the state change, e.g. "new:urn:oid:...". Each key is
+	// delivered once; receivers may use it to dedupe retries.
+	Key   string          `json:"key"`
+	Kind  string          `json:"kind"`
+	Title string          `json:"title"`
+	Alert fetcher.Warning `json:"alert"`
+	At    string          `json:"at"`
+	// Test marks a sample message from the notify test command. Targets
+	// deliver it whatever their kinds and rules.
+	Test bool `json:"test,omitempty"`
+}
+
+// Target receives messages. Send must not block the poller; Close stops
+// the target once its queued messages are delivered.
+type Target interface {
+	Send(m Message)
+	Close()
+}
+
+// Observer is a target that also wants the alerts active after every poll
+// cycle, such as one that sends periodic digests.
+type Observer interface {
+	Observe(warnings []fetcher.Warning)
+}
+
+// Dispatcher turns each poll cycle's lifecycle events into messages and
+// hands every state change to the targets exactly once.
+type Dispatcher struct {
+	targets []Target
+
+	mu    sync.Mutex
+	seen  map[string]time.Time
+	known map[string]knownAlert
+}
+
+type knownAlert struct {
+	alert    fetcher.Warning
+	lastSeen time.Time
+}
+
+// NewDispatcher returns a dispatcher that sends to targets.
+func NewDispatcher(targets ...Target) *Dispatcher {
+	return &Dispatcher{
+		targets: targets,
+		seen:    make(map[string]time.Time),
+		known:   make(map[string]knownAlert),
+	}
+}
+
+// Notify handles one poll cycle. It matches the generator's Notifier
+// interface.
+func (d *Dispatcher) Notify(events []lifecycle.Event, warnings []fetcher.Warning) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for _, w := range warnings {
+		d.known[w.ID] = knownAlert{alert: w, lastSeen: now}
+	}
+	for _, t := range d.targets {
+		if o, ok := t.(Observer); ok {
+			o.Observe(warnings)
+		}
+	}
+
+	// Events arrive newest first; send oldest first.
+	for i := len(events) - 1; i >= 0; i-- {
+		m, ok := d.message(events[i])
+		if !ok {
+			continue
+		}
+		if _, dup := d.seen[m.Key]; dup {
+			continue
+		}
+		d.seen[m.Key] = now
+		log.Printf("[notify] %s", m.Title)
+		for _, t := range d.targets {
+			t.Send(m)
+		}
+	}
+
+	for k, at := range d.seen {
+		if now.Sub(at) > seenRetention {
+			delete(d.seen, k)
+		}
+	}
+	for id, k := range d.known {
+		if now.Sub(k.lastSeen) > knownRetention {
+			delete(d.known, id)
+		}
+	}
+}
+
+// Close stops every target, waiting for queued messages to be delivered.
+func (d *Dispatcher) Close() {
+	for _, t := range d.targets {
+		t.Close()
+	}
+}
+
+// message builds the message for a lifecycle event. Cancellations are keyed
+// on the alert they end, so an NWS Cancel message and the alert then leaving
+// the feed count as one change.
+func (d *Dispatcher) message(e lifecycle.Event) (Message, bool) {
+	kind := ""
+	subject := e.ID
+	switch e.Kind {
+	case lifecycle.New:
+		kind = KindNew
+	case lifecycle.Updated, lifecycle.Extended:
+		kind = string(e.Kind)
+		if e.PreviousID != "" && fetcher.TierRank(e.Tier) > fetcher.TierRank(e.PreviousTier) {
+			kind = KindUpgraded
+		}
+	case lifecycle.Cancelled:
+		kind = KindCancelled
+		if e.PreviousID != "" {
+			subject = e.PreviousID
+		}
+	case lifecycle.Expired:
+		kind = KindExpired
+	default:
+		return Message{}, false
+	}
+
+	alert := fetcher.Warning{ID: subject, Type: e.Type, Area: e.Area, Tier: e.Tier, ExpiresTime: e.ExpiresTime}
+	if k, ok := d.known[subject]; ok {
+		alert = k.alert
+	}
+	return Message{
+		Key:   kind + ":" + subject,
+		Kind:  kind,
+		Title: title(kind, alert),
+		Alert: alert,
+		At:    e.At,
+	}, true
+}
+
+func title(kind string, a fetcher.Warning) string {
+	name := a.DisplayType()
+	switch kind {
+	case KindNew:
+		return name + " issued for " + a.Area
+	case KindUpgraded:
+		return "Upgraded to " + name + " for " + a.Area
+	default:
+		return name + " " + kind + " for " + a.Area
+	}
+}
+
+// retry calls attempt until it succeeds, reports a permanent failure or
+// has been retried retries times, backing off exponentially between tries.
+// attempt may ask for a longer wait, as a Retry-After header does.
+func retry(target, key string, retries int, attempt func() (bool, time.Duration, error)) {
+	backoff := 2 * time.Second
+	for n := 0; ; n++ {
+		again, wait, err := attempt()
+		if err == nil {
+			return
+		}
+		if !again || n >= retries {
+			log.Printf("[notify] %s: giving up on %s: %v", target, key, err)
+			return
+		}
+		if wait < backoff {
+			wait = backoff
+		}
+		log.Printf("[notify] %s: %v; retrying in %s", target, err, wait)
+		time.Sleep(wait)
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
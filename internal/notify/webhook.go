@@ -0,0 +1,273 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+	"github.com/Zachdehooge/warnings-dashboard/internal/rules"
+)
+
+// Webhook body formats.
+const (
+	FormatJSON    = "json"
+	FormatSlack   = "slack"
+	FormatDiscord = "discord"
+	FormatTeams   = "teams"
+)
+
+const (
+	defaultRateLimit = 30
+	defaultRetries   = 5
+	queueSize        = 256
+	// discordLimit is the most characters of message content Discord
+	// accepts.
+	discordLimit = 2000
+)
+
+// defaultText is the message text for chat formats without a template.
+const defaultText = `{{.Title}}
+{{if .Alert.Headline}}{{.Alert.Headline}}
+{{end}}{{if .Alert.ExpiresTime}}Expires: {{.Alert.ExpiresTime}}{{end}}`
+
+// WebhookConfig describes one webhook target.
+type WebhookConfig struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// Format is json (the Message as JSON, the default), slack, discord or
+	// teams.
+	Format string `yaml:"format"`
+	// Template is a text/template rendered with the Message. For chat
+	// formats it replaces the message text; for json it is the whole body.
+	Template string `yaml:"template"`
+	// ContentType overrides the Content-Type of templated json bodies.
+	ContentType string            `yaml:"contentType"`
+	Headers     map[string]string `yaml:"headers"`
+	// On lists the kinds of change to send; DefaultKinds when empty.
+	On []string `yaml:"on"`
+	// Rules selects which alerts to send; every alert when empty.
+	Rules *rules.Set `yaml:"rules"`
+	// RateLimit is the most messages sent per minute.
+	RateLimit int `yaml:"rateLimit"`
+	// Retries is how many times a failed delivery is retried; zero means
+	// the default and a negative value disables retries.
+	Retries int `yaml:"retries"`
+}
+
+// Webhook posts messages to a URL from a background worker, so slow or
+// failing receivers never hold up the poller. Deliveries are rate limited
+// and retried with exponential backoff.
+type Webhook struct {
+	cfg    WebhookConfig
+	kinds  map[string]bool
+	tmpl   *template.Template
+	client *http.Client
+	queue  chan Message
+	done   chan struct{}
+}
+
+// NewWebhook validates cfg and starts the webhook's delivery worker.
+func NewWebhook(cfg WebhookConfig) (*Webhook, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("webhook %q: url is required", cfg.Name)
+	}
+	if cfg.Name == "" {
+		cfg.Name = cfg.URL
+	}
+	switch cfg.Format {
+	case "":
+		cfg.Format = FormatJSON
+	case FormatJSON, FormatSlack, FormatDiscord, FormatTeams:
+	default:
+		return nil, fmt.Errorf("webhook %q: unknown format %q", cfg.Name, cfg.Format)
+	}
+	if cfg.Rules != nil {
+		if err := cfg.Rules.Validate(); err != nil {
+			return nil, fmt.Errorf("webhook %q: %w", cfg.Name, err)
+		}
+	}
+	if cfg.RateLimit <= 0 {
+		cfg.RateLimit = defaultRateLimit
+	}
+	if cfg.Retries < 0 {
+		cfg.Retries = 0
+	} else if cfg.Retries == 0 {
+		cfg.Retries = defaultRetries
+	}
+
+	kinds, err := parseKinds(cfg.On)
+	if err != nil {
+		return nil, fmt.Errorf("webhook %q: %w", cfg.Name, err)
+	}
+
+	text := cfg.Template
+	if text == "" && cfg.Format != FormatJSON {
+		text = defaultText
+	}
+	var tmpl *template.Template
+	if text != "" {
+		if tmpl, err = template.New(cfg.Name).Parse(text); err != nil {
+			return nil, fmt.Errorf("webhook %q: invalid template: %w", cfg.Name, err)
+		}
+	}
+
+	w := &Webhook{
+		cfg:    cfg,
+		kinds:  kinds,
+		tmpl:   tmpl,
+		client: &http.Client{Timeout: 15 * time.Second},
+		queue:  make(chan Message, queueSize),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// Send queues m if the webhook wants it. A full queue drops the message
+// rather than stall the poller.
+func (w *Webhook) Send(m Message) {
+	if !m.Test && (!w.kinds[m.Kind] || !w.cfg.Rules.Allow(m.Alert.RuleAlert())) {
+		return
+	}
+	select {
+	case w.queue <- m:
+	default:
+		log.Printf("[notify] webhook %s: queue full, dropped %s", w.cfg.Name, m.Key)
+	}
+}
+
+// Close stops the webhook and waits for queued messages to be delivered.
+func (w *Webhook) Close() {
+	close(w.queue)
+	<-w.done
+}
+
+func (w *Webhook) run() {
+	defer close(w.done)
+	interval := time.Minute / time.Duration(w.cfg.RateLimit)
+	var next time.Time
+	for m := range w.queue {
+		if wait := time.Until(next); wait > 0 {
+			time.Sleep(wait)
+		}
+		next = time.Now().Add(interval)
+		w.deliver(m)
+	}
+}
+
+// deliver posts m, retrying network errors, 429s and 5xx responses.
+func (w *Webhook) deliver(m Message) {
+	body, contentType, err := w.body(m)
+	if err != nil {
+		log.Printf("[notify] webhook %s: %v", w.cfg.Name, err)
+		return
+	}
+
+	retry("webhook "+w.cfg.Name, m.Key, w.cfg.Retries, func() (bool, time.Duration, error) {
+		return w.post(m, body, contentType)
+	})
+}
+
+// post makes one delivery attempt. It reports whether a failure is worth
+// retrying and any delay the receiver asked for.
+func (w *Webhook) post(m Message, body []byte, contentType string) (bool, time.Duration, error) {
+	req, err := http.NewRequest("POST", w.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, 0, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", fetcher.UserAgent(""))
+	req.Header.Set("Idempotency-Key", m.Key)
+	for k, v := range w.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, 0, nil
+	}
+	err = fmt.Errorf("HTTP %d", resp.StatusCode)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		secs, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return true, time.Duration(secs) * time.Second, err
+	}
+	return false, 0, err
+}
+
+// body renders m in the webhook's format.
+func (w *Webhook) body(m Message) ([]byte, string, error) {
+	if w.cfg.Format == FormatJSON {
+		if w.tmpl == nil {
+			data, err := json.Marshal(m)
+			return data, "application/json", err
+		}
+		var buf bytes.Buffer
+		if err := w.tmpl.Execute(&buf, m); err != nil {
+			return nil, "", fmt.Errorf("template failed: %w", err)
+		}
+		ct := w.cfg.ContentType
+		if ct == "" {
+			ct = "application/json"
+		}
+		return buf.Bytes(), ct, nil
+	}
+
+	var buf bytes.Buffer
+	if err := w.tmpl.Execute(&buf, m); err != nil {
+		return nil, "", fmt.Errorf("template failed: %w", err)
+	}
+	text := strings.TrimSpace(buf.String())
+
+	var v interface{}
+	switch w.cfg.Format {
+	case FormatSlack:
+		v = map[string]string{"text": text}
+	case FormatDiscord:
+		// The limit counts characters; cutting bytes could split one.
+		if r := []rune(text); len(r) > discordLimit {
+			text = string(r[:discordLimit-3]) + "..."
+		}
+		v = map[string]string{"content": text}
+	case FormatTeams:
+		v = map[string]string{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    m.Title,
+			"title":      m.Title,
+			"themeColor": themeColor(m),
+			"text":       strings.ReplaceAll(text, "\n", "\n\n"),
+		}
+	}
+	data, err := json.Marshal(v)
+	return data, "application/json", err
+}
+
+// themeColor matches the dashboard's colours for the alert's tier.
+func themeColor(m Message) string {
+	switch {
+	case m.Kind == KindCancelled || m.Kind == KindExpired:
+		return "808080"
+	case m.Alert.Tier == fetcher.TierEmergency:
+		return "FF00FF"
+	case m.Alert.Tier == fetcher.TierPDS:
+		return "FF6A00"
+	case strings.Contains(m.Alert.Type, "Tornado"):
+		return "FF0000"
+	default:
+		return "FFA500"
+	}
+}
@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestDiscordTruncatesByCharacter(t *testing.T) {
+	w, err := NewWebhook(WebhookConfig{URL: "http://127.0.0.1/hook", Format: FormatDiscord, Template: "{{.Title}}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// Two- and three-byte characters, so a byte cut at the limit would land
+	// inside one.
+	title := strings.Repeat("Añasco — ", 400)
+	data, _, err := w.body(Message{Title: title})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !utf8.Valid(data) {
+		t.Fatal("body is not valid UTF-8")
+	}
+	var v struct{ Content string }
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	if n := utf8.RuneCountInString(v.Content); n != discordLimit {
+		t.Errorf("content is %d characters, want %d", n, discordLimit)
+	}
+	if !strings.HasSuffix(v.Content, "...") || !strings.HasPrefix(title, strings.TrimSuffix(v.Content, "...")) {
+		t.Errorf("content is not the title cut short: %q", v.Content[len(v.Content)-20:])
+	}
+
+	// Short messages are sent as they are.
+	if data, _, _ = w.body(Message{Title: "Añasco — Flood Warning"}); !strings.Contains(string(data), `"Añasco — Flood Warning"`) {
+		t.Errorf("short message changed: %s", data)
+	}
+}
@@ -0,0 +1,200 @@
+package rules
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Action is what happens to an alert that matches a rule.
+type Action string
+
+const (
+	Include Action = "include"
+	Exclude Action = "exclude"
+)
+
+// Alert is the subset of an alert the rules are evaluated against.
+type Alert struct {
+	Event       string
+	Description string
+	Severity    string
+	Certainty   string
+	Urgency     string
+	UGC         []string
+	// Tier is "emergency", "pds" or empty for an ordinary alert.
+	Tier string
+}
+
+// Rule matches an alert when every non-empty field matches. Within a field
+// any one of the listed values is enough.
+type Rule struct {
+	Action      Action   `yaml:"action" json:"action"`
+	Events      []string `yaml:"events,omitempty" json:"events,omitempty"`
+	Description []string `yaml:"description,omitempty" json:"description,omitempty"`
+	Severity    []string `yaml:"severity,omitempty" json:"severity,omitempty"`
+	Certainty   []string `yaml:"certainty,omitempty" json:"certainty,omitempty"`
+	Urgency     []string `yaml:"urgency,omitempty" json:"urgency,omitempty"`
+	States      []string `yaml:"states,omitempty" json:"states,omitempty"`
+	UGC         []string `yaml:"ugc,omitempty" json:"ugc,omitempty"`
+	Tiers       []string `yaml:"tiers,omitempty" json:"tiers,omitempty"`
+}
+
+// Set is an ordered list of rules. The first matching rule decides; alerts
+// that match nothing get the default action.
+type Set struct {
+	Default Action `yaml:"default" json:"default"`
+	Rules   []Rule `yaml:"rules" json:"rules"`
+}
+
+// Default returns the built-in rule set: the convective products the
+// dashboard is built around are kept, anything mentioning fire is dropped,
+// and everything else is excluded.
+func Default() *Set {
+	return &Set{
+		Default: Exclude,
+		Rules: []Rule{
+			{Action: Exclude, Description: []string{"fire"}},
+			{Action: Include, Events: []string{
+				"tornado warning",
+				"tornado watch",
+				"severe thunderstorm warning",
+				"severe thunderstorm watch",
+				"special weather statement",
+			}},
+		},
+	}
+}
+
+// Load reads a rule set from a YAML or JSON file.
+func Load(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes a rule set from YAML or JSON and validates it.
+func Parse(data []byte) (*Set, error) {
+	var s Set
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse rules: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Validate checks a rule set decoded by some other means than Parse, such
+// as embedded in a larger config. An empty default action means include.
+func (s *Set) Validate() error {
+	if s.Default == "" {
+		s.Default = Include
+	}
+	if s.Default != Include && s.Default != Exclude {
+		return fmt.Errorf("invalid default action %q", s.Default)
+	}
+	for i, r := range s.Rules {
+		if r.Action != Include && r.Action != Exclude {
+			return fmt.Errorf("rule %d: invalid action %q", i+1, r.Action)
+		}
+		if r.empty() {
+			return fmt.Errorf("rule %d: no match fields", i+1)
+		}
+	}
+	return nil
+}
+
+// Marshal encodes the rule set as YAML.
+func (s *Set) Marshal() ([]byte, error) {
+	return yaml.Marshal(s)
+}
+
+// Allow reports whether an alert should be kept.
+func (s *Set) Allow(a Alert) bool {
+	if s == nil {
+		return true
+	}
+	for _, r := range s.Rules {
+		if r.Match(a) {
+			return r.Action == Include
+		}
+	}
+	return s.Default == Include
+}
+
+func (r Rule) empty() bool {
+	return len(r.Events) == 0 && len(r.Description) == 0 && len(r.Severity) == 0 &&
+		len(r.Certainty) == 0 && len(r.Urgency) == 0 && len(r.States) == 0 && len(r.UGC) == 0 &&
+		len(r.Tiers) == 0
+}
+
+// Match reports whether the alert satisfies every field set on the rule.
+// Events and descriptions match on case-insensitive substrings; severity,
+// certainty, urgency and tier match whole values; states and UGC match code
+// prefixes.
+func (r Rule) Match(a Alert) bool {
+	if r.empty() {
+		return false
+	}
+	if len(r.Events) > 0 && !containsAny(a.Event, r.Events) {
+		return false
+	}
+	if len(r.Description) > 0 && !containsAny(a.Description, r.Description) {
+		return false
+	}
+	if len(r.Severity) > 0 && !equalsAny(a.Severity, r.Severity) {
+		return false
+	}
+	if len(r.Certainty) > 0 && !equalsAny(a.Certainty, r.Certainty) {
+		return false
+	}
+	if len(r.Urgency) > 0 && !equalsAny(a.Urgency, r.Urgency) {
+		return false
+	}
+	if len(r.States) > 0 && !ugcPrefixAny(a.UGC, r.States) {
+		return false
+	}
+	if len(r.UGC) > 0 && !ugcPrefixAny(a.UGC, r.UGC) {
+		return false
+	}
+	if len(r.Tiers) > 0 && !equalsAny(a.Tier, r.Tiers) {
+		return false
+	}
+	return true
+}
+
+func containsAny(s string, subs []string) bool {
+	s = strings.ToLower(s)
+	for _, sub := range subs {
+		if strings.Contains(s, strings.ToLower(sub)) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalsAny(s string, values []string) bool {
+	for _, v := range values {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func ugcPrefixAny(codes []string, prefixes []string) bool {
+	for _, code := range codes {
+		code = strings.ToUpper(code)
+		for _, p := range prefixes {
+			if strings.HasPrefix(code, strings.ToUpper(p)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package rules
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Store holds the active rule set and swaps it when the backing file changes.
+// A Store with no path always serves Default().
+type Store struct {
+	path string
+
+	mu      sync.RWMutex
+	set     *Set
+	modTime time.Time
+}
+
+// NewStore loads the rule file at path, or the built-in defaults when path is empty.
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, set: Default()}
+	if path == "" {
+		return s, nil
+	}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Path returns the rule file backing the store, or "" for the defaults.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Current returns the active rule set.
+func (s *Store) Current() *Set {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.set
+}
+
+// Reload re-reads the rule file. On error the previous rule set stays active.
+func (s *Store) Reload() error {
+	if s.path == "" {
+		return nil
+	}
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat rules: %w", err)
+	}
+	set, err := Load(s.path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.set = set
+	s.modTime = info.ModTime()
+	s.mu.Unlock()
+	return nil
+}
+
+// ReloadIfChanged reloads the rule file when its modification time has moved
+// since the last successful load. It reports whether a reload happened.
+func (s *Store) ReloadIfChanged() (bool, error) {
+	if s.path == "" {
+		return false, nil
+	}
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat rules: %w", err)
+	}
+
+	s.mu.RLock()
+	unchanged := info.ModTime().Equal(s.modTime)
+	s.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+	if err := s.Reload(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,342 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/coverage"
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+	"github.com/Zachdehooge/warnings-dashboard/internal/generator"
+	"github.com/Zachdehooge/warnings-dashboard/internal/geo"
+)
+
+// registerAPI adds the versioned JSON API. Every endpoint answers from the
+// poller's latest cycle; only /check may reach out, to look up a point's zones.
+func (s *Server) registerAPI(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/alerts", s.apiAlerts)
+	mux.HandleFunc("GET /api/v1/alerts/{id}", s.apiAlert)
+	mux.HandleFunc("GET /api/v1/mcds", s.apiMCDs)
+	mux.HandleFunc("GET /api/v1/summary", s.apiSummary)
+	mux.HandleFunc("GET /api/v1/check", s.apiCheck)
+	s.registerExports(mux)
+}
+
+// snapshot returns the latest payload, or writes 503 and returns nil before
+// the first poll has finished.
+func (s *Server) snapshot(w http.ResponseWriter) *generator.PolledPayload {
+	s.mu.RLock()
+	state := s.state
+	s.mu.RUnlock()
+	if state == nil {
+		writeError(w, http.StatusServiceUnavailable, "no data yet, try again shortly")
+	}
+	return state
+}
+
+// activeAlerts drops alerts that expired since the last poll, judged on the
+// source's clock so replays behave like live data.
+func activeAlerts(state *generator.PolledPayload) []fetcher.Warning {
+	now := time.Now().Add(time.Duration(state.SourceOffset) * time.Second)
+	out := make([]fetcher.Warning, 0, len(state.Warnings))
+	for _, w := range state.Warnings {
+		if exp, err := time.Parse(time.RFC3339, w.ExpiresTime); err == nil && !exp.After(now) {
+			continue
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+func (s *Server) apiAlerts(w http.ResponseWriter, r *http.Request) {
+	state := s.snapshot(w)
+	if state == nil {
+		return
+	}
+	f, err := parseAlertFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	alerts := []fetcher.Warning{}
+	for _, a := range activeAlerts(state) {
+		if f.match(a) {
+			alerts = append(alerts, a)
+		}
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Alerts       []fetcher.Warning `json:"alerts"`
+		Count        int               `json:"count"`
+		UpdatedAtUTC int64             `json:"updatedAtUTC"`
+	}{alerts, len(alerts), state.UpdatedAtUTC})
+}
+
+func (s *Server) apiAlert(w http.ResponseWriter, r *http.Request) {
+	state := s.snapshot(w)
+	if state == nil {
+		return
+	}
+	id := r.PathValue("id")
+	for _, a := range activeAlerts(state) {
+		if a.ID == id {
+			writeJSON(w, http.StatusOK, a)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("no active alert %q", id))
+}
+
+func (s *Server) apiMCDs(w http.ResponseWriter, r *http.Request) {
+	state := s.snapshot(w)
+	if state == nil {
+		return
+	}
+	f, err := parseAlertFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	mcds := []generator.MesoscaleDiscussionJSON{}
+	for _, m := range state.MesoscaleDiscussions {
+		if f.matchGeometry(m.Geometry) {
+			mcds = append(mcds, m)
+		}
+	}
+	writeJSON(w, http.StatusOK, struct {
+		MesoscaleDiscussions []generator.MesoscaleDiscussionJSON `json:"mesoscaleDiscussions"`
+		Count                int                                 `json:"count"`
+		UpdatedAtUTC         int64                               `json:"updatedAtUTC"`
+	}{mcds, len(mcds), state.UpdatedAtUTC})
+}
+
+func (s *Server) apiSummary(w http.ResponseWriter, r *http.Request) {
+	state := s.snapshot(w)
+	if state == nil {
+		return
+	}
+	f, err := parseAlertFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sum := struct {
+		Total                int            `json:"total"`
+		ByType               map[string]int `json:"byType"`
+		BySeverity           map[string]int `json:"bySeverity"`
+		ByState              map[string]int `json:"byState"`
+		Emergencies          int            `json:"emergencies"`
+		PDS                  int            `json:"pds"`
+		MesoscaleDiscussions int            `json:"mesoscaleDiscussions"`
+		LastUpdated          string         `json:"lastUpdated"`
+		UpdatedAtUTC         int64          `json:"updatedAtUTC"`
+	}{
+		ByType:       map[string]int{},
+		BySeverity:   map[string]int{},
+		ByState:      map[string]int{},
+		LastUpdated:  state.LastUpdated,
+		UpdatedAtUTC: state.UpdatedAtUTC,
+	}
+	for _, a := range activeAlerts(state) {
+		if !f.match(a) {
+			continue
+		}
+		sum.Total++
+		sum.ByType[a.Type]++
+		sum.BySeverity[a.Severity]++
+		for st := range alertStates(a) {
+			sum.ByState[st]++
+		}
+		switch a.Tier {
+		case fetcher.TierEmergency:
+			sum.Emergencies++
+		case fetcher.TierPDS:
+			sum.PDS++
+		}
+	}
+	for _, m := range state.MesoscaleDiscussions {
+		if f.matchGeometry(m.Geometry) {
+			sum.MesoscaleDiscussions++
+		}
+	}
+	writeJSON(w, http.StatusOK, sum)
+}
+
+// apiCheck answers whether point=lat,lon is under an active alert. Zone
+// codes come from ugc= and same= or, when neither is given, from the
+// server's Resolver.
+func (s *Server) apiCheck(w http.ResponseWriter, r *http.Request) {
+	state := s.snapshot(w)
+	if state == nil {
+		return
+	}
+	q := r.URL.Query()
+	if q.Get("point") == "" {
+		writeError(w, http.StatusBadRequest, "point=lat,lon is required")
+		return
+	}
+	lat, lon, err := fetcher.ParsePoint(q.Get("point"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	loc := coverage.Location{Lat: lat, Lon: lon}
+	for code := range valueSet(q, "ugc", strings.ToUpper) {
+		loc.UGC = append(loc.UGC, code)
+	}
+	for code := range valueSet(q, "same", strings.TrimSpace) {
+		loc.SAME = append(loc.SAME, code)
+	}
+	loc.NormalizeCodes()
+	if len(loc.UGC) == 0 && len(loc.SAME) == 0 && s.Resolver != nil {
+		if err := s.Resolver.Resolve(&loc); err != nil {
+			log.Printf("[api] zone lookup for %s failed, checking polygons only: %v", q.Get("point"), err)
+		}
+	}
+	writeJSON(w, http.StatusOK, coverage.CheckLocation(activeAlerts(state), loc))
+}
+
+// alertFilter holds the query filters shared by the API endpoints. Each
+// list parameter accepts repeated or comma-separated values; an empty
+// filter matches everything.
+type alertFilter struct {
+	types      map[string]bool
+	severities map[string]bool
+	states     map[string]bool
+	ugc        map[string]bool
+	bbox       *geo.BBox
+	point      *[2]float64 // lon, lat
+}
+
+func parseAlertFilter(q url.Values) (alertFilter, error) {
+	f := alertFilter{
+		types:      valueSet(q, "type", strings.ToLower),
+		severities: valueSet(q, "severity", strings.ToLower),
+		states:     valueSet(q, "state", strings.ToUpper),
+		ugc:        valueSet(q, "ugc", strings.ToUpper),
+	}
+	if v := q.Get("bbox"); v != "" {
+		parts := strings.Split(v, ",")
+		if len(parts) != 4 {
+			return f, fmt.Errorf("bbox must be minLon,minLat,maxLon,maxLat")
+		}
+		var n [4]float64
+		for i, p := range parts {
+			x, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+			if err != nil {
+				return f, fmt.Errorf("bbox must be minLon,minLat,maxLon,maxLat")
+			}
+			n[i] = x
+		}
+		f.bbox = &geo.BBox{MinLon: n[0], MinLat: n[1], MaxLon: n[2], MaxLat: n[3]}
+	}
+	if v := q.Get("point"); v != "" {
+		lat, lon, err := fetcher.ParsePoint(v)
+		if err != nil {
+			return f, err
+		}
+		f.point = &[2]float64{lon, lat}
+	}
+	return f, nil
+}
+
+func valueSet(q url.Values, key string, norm func(string) string) map[string]bool {
+	set := map[string]bool{}
+	for _, v := range q[key] {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				set[norm(part)] = true
+			}
+		}
+	}
+	return set
+}
+
+func (f alertFilter) match(a fetcher.Warning) bool {
+	if len(f.types) > 0 && !f.types[strings.ToLower(a.Type)] {
+		return false
+	}
+	if len(f.severities) > 0 && !f.severities[strings.ToLower(a.Severity)] {
+		return false
+	}
+	if len(f.states) > 0 && !anyIn(f.states, alertStates(a)) {
+		return false
+	}
+	if len(f.ugc) > 0 && !anyOf(f.ugc, a.UGC) {
+		return false
+	}
+	return f.matchGeometry(a.Geometry)
+}
+
+// matchGeometry applies the bbox and point filters. Without a geometry
+// nothing is known about the shape, so a spatial filter excludes it.
+func (f alertFilter) matchGeometry(g *fetcher.Geometry) bool {
+	if f.bbox == nil && f.point == nil {
+		return true
+	}
+	if g == nil {
+		return false
+	}
+	if f.bbox != nil {
+		b, ok := geo.GeometryBounds(g.Coordinates)
+		if !ok || !b.Intersects(*f.bbox) {
+			return false
+		}
+	}
+	if f.point != nil && !geo.ContainsPoint(g.Type, g.Coordinates, f.point[0], f.point[1]) {
+		return false
+	}
+	return true
+}
+
+// alertStates returns the two-letter state codes of an alert's UGC zones.
+func alertStates(a fetcher.Warning) map[string]bool {
+	states := map[string]bool{}
+	for _, code := range a.UGC {
+		if len(code) >= 2 {
+			states[strings.ToUpper(code[:2])] = true
+		}
+	}
+	return states
+}
+
+func anyIn(want, have map[string]bool) bool {
+	for k := range have {
+		if want[k] {
+			return true
+		}
+	}
+	return false
+}
+
+func anyOf(want map[string]bool, have []string) bool {
+	for _, v := range have {
+		if want[strings.ToUpper(v)] {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{msg})
+}
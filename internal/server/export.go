@@ -0,0 +1,87 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/export"
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+)
+
+// registerExports adds /api/v1/alerts.<format> for every export format,
+// taking the same filters as /api/v1/alerts, and /api/v1/alerts/{id}/cap
+// for a single CAP 1.2 alert.
+func (s *Server) registerExports(mux *http.ServeMux) {
+	for _, format := range export.Formats {
+		mux.HandleFunc("GET /api/v1/alerts."+format, s.exportAlerts(format))
+	}
+	mux.HandleFunc("GET /api/v1/alerts/{id}/cap", s.exportAlertCAP)
+}
+
+func (s *Server) exportAlerts(format string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		state := s.snapshot(w)
+		if state == nil {
+			return
+		}
+		f, err := parseAlertFilter(r.URL.Query())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		alerts := []fetcher.Warning{}
+		for _, a := range activeAlerts(state) {
+			if f.match(a) {
+				alerts = append(alerts, a)
+			}
+		}
+		var buf bytes.Buffer
+		err = export.Encode(&buf, format, alerts, export.Options{
+			Link:    dashboardURL(r),
+			Updated: time.Unix(state.UpdatedAtUTC, 0),
+		})
+		writeExport(w, format, buf.Bytes(), err)
+	}
+}
+
+func (s *Server) exportAlertCAP(w http.ResponseWriter, r *http.Request) {
+	state := s.snapshot(w)
+	if state == nil {
+		return
+	}
+	id := r.PathValue("id")
+	for _, a := range activeAlerts(state) {
+		if a.ID == id {
+			var buf bytes.Buffer
+			err := export.EncodeCAP(&buf, a)
+			writeExport(w, export.CAP, buf.Bytes(), err)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("no active alert %q", id))
+}
+
+func writeExport(w http.ResponseWriter, format string, body []byte, err error) {
+	if err != nil {
+		log.Printf("[api] %s export failed: %v", format, err)
+		writeError(w, http.StatusInternalServerError, "export failed")
+		return
+	}
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(body)
+}
+
+// dashboardURL is the page's address as the client reached it, which feeds
+// link their entries to.
+func dashboardURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + "/"
+}
@@ -0,0 +1,254 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/coverage"
+	"github.com/Zachdehooge/warnings-dashboard/internal/generator"
+)
+
+// keepAlive is how often an idle event stream gets a comment line, so
+// proxies don't close it.
+const keepAlive = 25 * time.Second
+
+// Server serves the dashboard page and the poller's latest payload from
+// memory. Each body is hashed and gzipped once when it is set, so polling
+// browsers cost a header comparison, not a file read and a full download.
+type Server struct {
+	// Resolver, when set, looks up the zones of points passed to
+	// /api/v1/check without codes.
+	Resolver *coverage.Resolver
+	// Tiles, when set, serves the map's basemap and radar tiles under
+	// /tiles/.
+	Tiles http.Handler
+
+	mu      sync.RWMutex
+	page    *resource
+	payload *resource
+	// payloadKey identifies payload's content apart from its timestamps.
+	payloadKey [sha256.Size]byte
+	state      *generator.PolledPayload
+	static     *staticFiles
+
+	subMu sync.Mutex
+	subs  map[chan []byte]struct{}
+}
+
+type resource struct {
+	body        []byte
+	gzipped     []byte
+	etag        string
+	contentType string
+}
+
+// New returns a server with nothing to serve yet; it answers 503 until
+// SetPage and SetPayload are called.
+func New() *Server {
+	return &Server{subs: make(map[chan []byte]struct{})}
+}
+
+// SetPage replaces the dashboard HTML.
+func (s *Server) SetPage(html []byte) {
+	r := newResource(html, "text/html; charset=utf-8")
+	s.mu.Lock()
+	s.page = r
+	s.mu.Unlock()
+}
+
+// Publish replaces the warnings.json body and the state the API answers
+// from. It matches the Poller's Publish signature. A payload that differs
+// from the last one only in its update time keeps the old body, so
+// browsers polling with If-None-Match still get a 304.
+func (s *Server) Publish(payload *generator.PolledPayload, data []byte) {
+	key := contentKey(payload)
+	s.mu.RLock()
+	same := s.payload != nil && key == s.payloadKey
+	s.mu.RUnlock()
+
+	var r *resource
+	if !same {
+		r = newResource(data, "application/json")
+	}
+	s.mu.Lock()
+	if r != nil {
+		s.payload = r
+		s.payloadKey = key
+	}
+	s.state = payload
+	s.mu.Unlock()
+}
+
+// contentKey hashes a payload with its update timestamps cleared.
+func contentKey(payload *generator.PolledPayload) [sha256.Size]byte {
+	p := *payload
+	p.LastUpdated = ""
+	p.UpdatedAtUTC = 0
+	data, _ := json.Marshal(p)
+	return sha256.Sum256(data)
+}
+
+// PushDelta sends an encoded delta to every connected event stream. It
+// matches the Poller's PublishDelta signature. A client too slow to keep up
+// misses the delta and resynchronises from the sequence gap.
+func (s *Server) PushDelta(data []byte) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	for ch := range s.subs {
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
+
+// Handler routes / and /warnings.html to the page, /warnings.json to the
+// payload, /static/ to the page's assets, /tiles/ to the tile proxy,
+// /events to the Server-Sent Events delta stream and /api/v1/ to the JSON
+// API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", s.serveEvents)
+	mux.HandleFunc("/static/", s.serveStatic)
+	if s.Tiles != nil {
+		mux.Handle("/tiles/", http.StripPrefix("/tiles", s.Tiles))
+	}
+	s.registerAPI(mux)
+	mux.HandleFunc("/warnings.json", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		res := s.payload
+		s.mu.RUnlock()
+		res.serve(w, r)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" && r.URL.Path != "/warnings.html" {
+			http.NotFound(w, r)
+			return
+		}
+		s.mu.RLock()
+		res := s.page
+		s.mu.RUnlock()
+		res.serve(w, r)
+	})
+	return mux
+}
+
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := make(chan []byte, 8)
+	s.subMu.Lock()
+	s.subs[ch] = struct{}{}
+	s.subMu.Unlock()
+	defer func() {
+		s.subMu.Lock()
+		delete(s.subs, ch)
+		s.subMu.Unlock()
+	}()
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	fmt.Fprint(w, "retry: 5000\n\n")
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data := <-ch:
+			fmt.Fprintf(w, "event: delta\ndata: %s\n\n", data)
+			flusher.Flush()
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func newResource(body []byte, contentType string) *resource {
+	sum := sha256.Sum256(body)
+	r := &resource{
+		body:        body,
+		etag:        `"` + hex.EncodeToString(sum[:12]) + `"`,
+		contentType: contentType,
+	}
+
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if _, err := zw.Write(body); err == nil && zw.Close() == nil && buf.Len() < len(body) {
+		r.gzipped = buf.Bytes()
+	}
+	return r
+}
+
+func (r *resource) serve(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r == nil {
+		http.Error(w, "dashboard is starting, try again shortly", http.StatusServiceUnavailable)
+		return
+	}
+
+	body, etag := r.body, r.etag
+	gz := r.gzipped != nil && acceptsGzip(req.Header.Get("Accept-Encoding"))
+	if gz {
+		// Each encoding is its own representation, so it gets its own tag.
+		body, etag = r.gzipped, strings.TrimSuffix(r.etag, `"`)+`-gz"`
+	}
+
+	h := w.Header()
+	// no-cache lets browsers keep a copy but makes them revalidate it with
+	// If-None-Match on every request.
+	h.Set("Cache-Control", "no-cache")
+	h.Set("ETag", etag)
+	h.Set("Vary", "Accept-Encoding")
+	if etagMatches(req.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.Set("Content-Type", r.contentType)
+	if gz {
+		h.Set("Content-Encoding", "gzip")
+	}
+	w.Write(body)
+}
+
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			return strings.ReplaceAll(params, " ", "") != "q=0"
+		}
+	}
+	return false
+}
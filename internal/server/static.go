@@ -0,0 +1,61 @@
+package server
+
+import (
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// staticFiles are the front end's stylesheet, script and Leaflet copy.
+type staticFiles struct {
+	files fs.FS
+	// live files are re-read on every request; otherwise each is read,
+	// hashed and gzipped on first use and kept.
+	live  bool
+	cache map[string]*resource
+}
+
+// SetStatic sets the files served under /static/. Pass live when they come
+// from a directory being edited, so a reload picks up changes.
+func (s *Server) SetStatic(files fs.FS, live bool) {
+	st := &staticFiles{files: files, live: live, cache: map[string]*resource{}}
+	s.mu.Lock()
+	s.static = st
+	s.mu.Unlock()
+}
+
+func (s *Server) serveStatic(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean(r.URL.Path), "/static/")
+	s.mu.RLock()
+	st := s.static
+	var res *resource
+	if st != nil && !st.live {
+		res = st.cache[name]
+	}
+	s.mu.RUnlock()
+
+	if res == nil {
+		if st == nil || !fs.ValidPath(name) {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := fs.ReadFile(st.files, name)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		ct := mime.TypeByExtension(path.Ext(name))
+		if ct == "" {
+			ct = http.DetectContentType(data)
+		}
+		res = newResource(data, ct)
+		if !st.live {
+			s.mu.Lock()
+			st.cache[name] = res
+			s.mu.Unlock()
+		}
+	}
+	res.serve(w, r)
+}
@@ -0,0 +1,291 @@
+package tiles
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxFetches caps concurrent upstream requests, so a browser opening the
+// map doesn't fire dozens of requests at a third-party server at once.
+const maxFetches = 4
+
+// errNotFound is an upstream 404, which is passed on rather than treated
+// as an outage.
+var errNotFound = errors.New("tile not found upstream")
+
+var (
+	client  = &http.Client{Timeout: 20 * time.Second}
+	fetches = make(chan struct{}, maxFetches)
+)
+
+// cache keeps tiles as files under dir, named by key, up to limit bytes.
+// An index of the files in least recently used order decides which go
+// when it fills up.
+type cache struct {
+	dir   string
+	limit int64
+
+	mu       sync.Mutex
+	inflight map[string]*call
+	entries  map[string]*list.Element
+	lru      *list.List // of *entry, most recently used first
+	size     int64
+}
+
+type entry struct {
+	key  string
+	size int64
+}
+
+// call is an upstream fetch other requests for the same tile wait on.
+type call struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// newCache indexes the tiles already in dir, oldest last, and trims them
+// to limit.
+func newCache(dir string, limit int64) *cache {
+	c := &cache{
+		dir:      dir,
+		limit:    limit,
+		inflight: make(map[string]*call),
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+	type file struct {
+		key  string
+		size int64
+		mod  time.Time
+	}
+	var files []file
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".tile-") {
+			os.Remove(path)
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil
+		}
+		files = append(files, file{filepath.ToSlash(rel), info.Size(), info.ModTime()})
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].mod.After(files[j].mod) })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, f := range files {
+		c.entries[f.key] = c.lru.PushBack(&entry{f.key, f.size})
+		c.size += f.size
+	}
+	c.evict()
+	return c
+}
+
+func (c *cache) path(key string) string {
+	return filepath.Join(c.dir, filepath.FromSlash(key))
+}
+
+// get returns the cached tile and when it was stored.
+func (c *cache) get(key string) ([]byte, time.Time, bool) {
+	path := c.path(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(el)
+	}
+	c.mu.Unlock()
+	return data, info.ModTime(), true
+}
+
+// put stores a tile, writing a temporary file first so readers never see
+// part of one, and evicts the least recently used tiles beyond the limit.
+func (c *cache) put(key string, data []byte) {
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Printf("[tiles] cache: %v", err)
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tile-*")
+	if err != nil {
+		log.Printf("[tiles] cache: %v", err)
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		log.Printf("[tiles] cache: %v", err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry)
+		c.size += int64(len(data)) - e.size
+		e.size = int64(len(data))
+		c.lru.MoveToFront(el)
+	} else {
+		c.entries[key] = c.lru.PushFront(&entry{key, int64(len(data))})
+		c.size += int64(len(data))
+	}
+	c.evict()
+}
+
+// evict removes least recently used tiles until the cache fits its limit.
+// The caller holds c.mu.
+func (c *cache) evict() {
+	for c.size > c.limit {
+		el := c.lru.Back()
+		if el == nil {
+			return
+		}
+		c.remove(el)
+	}
+}
+
+// remove deletes one indexed tile. The caller holds c.mu.
+func (c *cache) remove(el *list.Element) {
+	e := el.Value.(*entry)
+	if err := os.Remove(c.path(e.key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("[tiles] cache: %v", err)
+	}
+	c.lru.Remove(el)
+	delete(c.entries, e.key)
+	c.size -= e.size
+}
+
+// fetch returns the tile cached under key, fetching it from url when there
+// is no copy younger than maxAge; a negative maxAge never expires. If the
+// upstream fails, an expired copy is returned instead of the error.
+func (p *Proxy) fetch(key, url string, maxAge time.Duration) ([]byte, error) {
+	c := p.cache
+	if c == nil {
+		return p.download(url)
+	}
+	data, stored, ok := c.get(key)
+	if ok && (maxAge < 0 || time.Since(stored) < maxAge) {
+		return data, nil
+	}
+
+	// Requests for a tile already being fetched wait for that fetch.
+	c.mu.Lock()
+	if cl, busy := c.inflight[key]; busy {
+		c.mu.Unlock()
+		<-cl.done
+		return cl.data, cl.err
+	}
+	cl := &call{done: make(chan struct{})}
+	c.inflight[key] = cl
+	c.mu.Unlock()
+
+	cl.data, cl.err = p.download(url)
+	if cl.err == nil {
+		c.put(key, cl.data)
+	} else if ok && !errors.Is(cl.err, errNotFound) {
+		log.Printf("[tiles] %s: serving cached copy from %s: %v", key, stored.Format(time.RFC3339), cl.err)
+		cl.data, cl.err = data, nil
+	}
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	c.mu.Unlock()
+	close(cl.done)
+	return cl.data, cl.err
+}
+
+// download fetches one tile. WMS servers report errors as XML documents
+// with a 200 status, so anything that isn't an image is an error too.
+func (p *Proxy) download(url string) ([]byte, error) {
+	fetches <- struct{}{}
+	defer func() { <-fetches }()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.UserAgent != "" {
+		req.Header.Set("User-Agent", p.UserAgent)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, errNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("upstream returned %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+	if ct := http.DetectContentType(data); !strings.HasPrefix(ct, "image/") {
+		return nil, fmt.Errorf("upstream returned %s, not an image", ct)
+	}
+	return data, nil
+}
+
+// prune removes the tiles under radar/ of frames other than keep and
+// latest.
+func (c *cache) prune(keep map[string]bool) {
+	c.mu.Lock()
+	for key, el := range c.entries {
+		rest, ok := strings.CutPrefix(key, "radar/")
+		if !ok {
+			continue
+		}
+		if frame, _, _ := strings.Cut(rest, "/"); frame != "latest" && !keep[frame] {
+			c.remove(el)
+		}
+	}
+	c.mu.Unlock()
+
+	// Drop the emptied frame directories.
+	entries, err := os.ReadDir(c.path("radar"))
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() && e.Name() != "latest" && !keep[e.Name()] {
+			if err := os.RemoveAll(c.path("radar/" + e.Name())); err != nil {
+				log.Printf("[tiles] cache: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package tiles
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newCache(t.TempDir(), 100)
+	tile := bytes.Repeat([]byte{1}, 40)
+	c.put("basemap/a/1/0/0", tile)
+	c.put("basemap/a/1/0/1", tile)
+	if _, _, ok := c.get("basemap/a/1/0/0"); !ok {
+		t.Fatal("first tile missing before the cache is full")
+	}
+	c.put("basemap/a/1/1/0", tile)
+
+	if _, _, ok := c.get("basemap/a/1/0/1"); ok {
+		t.Error("least recently used tile was kept")
+	}
+	for _, key := range []string{"basemap/a/1/0/0", "basemap/a/1/1/0"} {
+		if _, _, ok := c.get(key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+	if c.size != 80 {
+		t.Errorf("size = %d, want 80", c.size)
+	}
+
+	// A new cache over the same directory picks the tiles back up.
+	if d := newCache(c.dir, 100); d.size != 80 || len(d.entries) != 2 {
+		t.Errorf("reindexed %d bytes in %d tiles, want 80 in 2", d.size, len(d.entries))
+	}
+}
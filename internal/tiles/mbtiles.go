@@ -0,0 +1,93 @@
+package tiles
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	_ "modernc.org/sqlite"
+)
+
+// mbtiles is a read-only MBTiles 1.3 raster tile set.
+type mbtiles struct {
+	db   *sql.DB
+	meta mbtilesMeta
+}
+
+type mbtilesMeta struct {
+	format           string
+	attribution      string
+	minZoom, maxZoom int
+}
+
+func openMBTiles(path string) (*mbtiles, error) {
+	// SQLite reports a missing file in read-only mode obscurely, so check
+	// for it first.
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open MBTiles: %w", err)
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	meta, err := readMeta(db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return &mbtiles{db: db, meta: meta}, nil
+}
+
+// readMeta reads the metadata table. Vector tiles (pbf) need a renderer
+// the page doesn't have, so only raster formats are accepted.
+func readMeta(db *sql.DB) (mbtilesMeta, error) {
+	rows, err := db.Query("SELECT name, value FROM metadata")
+	if err != nil {
+		return mbtilesMeta{}, err
+	}
+	defer rows.Close()
+	values := make(map[string]string)
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return mbtilesMeta{}, err
+		}
+		values[name] = value
+	}
+	if err := rows.Err(); err != nil {
+		return mbtilesMeta{}, err
+	}
+
+	m := mbtilesMeta{format: values["format"], attribution: values["attribution"]}
+	switch m.format {
+	case "":
+		m.format = "png"
+	case "png", "jpg", "webp":
+	default:
+		return mbtilesMeta{}, fmt.Errorf("unsupported tile format %q, need png, jpg or webp", m.format)
+	}
+	m.minZoom, _ = strconv.Atoi(values["minzoom"])
+	m.maxZoom, _ = strconv.Atoi(values["maxzoom"])
+	return m, nil
+}
+
+// tile returns a tile's image, or nil when the set doesn't have it.
+// MBTiles numbers rows from the south (TMS), so y is flipped.
+func (m *mbtiles) tile(t tile) ([]byte, error) {
+	row := (1 << t.z) - 1 - t.y
+	var data []byte
+	err := m.db.QueryRow(
+		"SELECT tile_data FROM tiles WHERE zoom_level = ? AND tile_column = ? AND tile_row = ?",
+		t.z, t.x, row,
+	).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (m *mbtiles) Close() error {
+	return m.db.Close()
+}
@@ -0,0 +1,161 @@
+package tiles
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// frameKey names a frame in tile URLs and the cache.
+const frameKey = "200601021504"
+
+// latestMaxAge is how long a tile of the latest radar image is reused.
+// Timestamped frames never change, so they are kept until they leave the
+// animation.
+const latestMaxAge = time.Minute
+
+// frame is one timestamped radar image.
+type frame struct {
+	Key  string    `json:"key"`
+	Time time.Time `json:"time"`
+}
+
+func (p *Proxy) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+// frames returns the animation's frames, oldest first. They fall on
+// multiples of the interval, the newest at least the delay old.
+func (p *Proxy) frames() []frame {
+	r := p.radar
+	newest := p.now().UTC().Add(-r.Delay).Truncate(r.Interval)
+	frames := make([]frame, r.Frames)
+	for i := range frames {
+		t := newest.Add(-time.Duration(r.Frames-1-i) * r.Interval)
+		frames[i] = frame{Key: t.Format(frameKey), Time: t}
+	}
+	return frames
+}
+
+// frameTime parses a frame key, accepting frames up to two intervals older
+// than the animation, for pages that have not re-read the list yet. Other
+// times are refused, so clients can't make the proxy fetch arbitrary
+// history.
+func (p *Proxy) frameTime(key string) (time.Time, bool) {
+	t, err := time.Parse(frameKey, key)
+	if err != nil || p.radar.Frames == 0 {
+		return time.Time{}, false
+	}
+	frames := p.frames()
+	oldest := frames[0].Time.Add(-2 * p.radar.Interval)
+	newest := frames[len(frames)-1].Time
+	if t.Before(oldest) || t.After(newest) || !t.Truncate(p.radar.Interval).Equal(t) {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func (p *Proxy) serveFrames(w http.ResponseWriter) {
+	frames := p.frames()
+	p.pruneFrames()
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	h.Set("Cache-Control", "no-cache")
+	json.NewEncoder(w).Encode(struct {
+		Frames []frame `json:"frames"`
+	}{frames})
+}
+
+// pruneFrames drops cached frames that have left the animation, at most
+// once an interval.
+func (p *Proxy) pruneFrames() {
+	if p.cache == nil || p.radar.Frames == 0 {
+		return
+	}
+	p.pruneMu.Lock()
+	defer p.pruneMu.Unlock()
+	if time.Since(p.lastPrune) < p.radar.Interval {
+		return
+	}
+	p.lastPrune = time.Now()
+
+	keep := make(map[string]bool)
+	frames := p.frames()
+	for i := -2; i < len(frames); i++ {
+		t := frames[0].Time.Add(time.Duration(i) * p.radar.Interval)
+		keep[t.Format(frameKey)] = true
+	}
+	go p.cache.prune(keep)
+}
+
+func (p *Proxy) serveRadar(w http.ResponseWriter, key string, t tile) {
+	if t.z > p.maxZoom {
+		http.Error(w, "no such tile", http.StatusNotFound)
+		return
+	}
+	if key == "latest" {
+		when := ""
+		if strings.Contains(p.radar.URL, "{time}") {
+			when = p.now().UTC().Add(-p.radar.Delay).Truncate(p.radar.Interval).Format(p.radar.TimeFormat)
+		}
+		p.serveUpstream(w, "radar/latest/"+p.radarKey+"/"+t.String(), p.radarURL(t, when), latestMaxAge, latestMaxAge)
+		return
+	}
+	at, ok := p.frameTime(key)
+	if !ok {
+		http.Error(w, "no such radar frame", http.StatusNotFound)
+		return
+	}
+	p.serveUpstream(w, "radar/"+key+"/"+p.radarKey+"/"+t.String(), p.radarURL(t, at.Format(p.radar.TimeFormat)), -1, 24*time.Hour)
+}
+
+// radarURL is the upstream address of a radar tile, at the time when for
+// timestamped frames.
+func (p *Proxy) radarURL(t tile, when string) string {
+	r := p.radar
+	if r.xyz() {
+		return expand(r.URL, t, when)
+	}
+	q := url.Values{
+		"SERVICE":     {"WMS"},
+		"REQUEST":     {"GetMap"},
+		"VERSION":     {"1.1.1"},
+		"LAYERS":      {r.Layers},
+		"STYLES":      {""},
+		"FORMAT":      {"image/png"},
+		"TRANSPARENT": {"true"},
+		"SRS":         {"EPSG:3857"},
+		"BBOX":        {mercatorBBox(t)},
+		"WIDTH":       {"256"},
+		"HEIGHT":      {"256"},
+	}
+	if when != "" {
+		q.Set("TIME", when)
+	}
+	sep := "?"
+	if strings.Contains(r.URL, "?") {
+		sep = "&"
+	}
+	return r.URL + sep + q.Encode()
+}
+
+// mercatorBBox is the extent of a tile in EPSG:3857 metres, as a WMS BBOX.
+func mercatorBBox(t tile) string {
+	const origin = 20037508.342789244
+	size := 2 * origin / math.Exp2(float64(t.z))
+	minX := -origin + float64(t.x)*size
+	maxY := origin - float64(t.y)*size
+	coords := []float64{minX, maxY - size, minX + size, maxY}
+	parts := make([]string, len(coords))
+	for i, c := range coords {
+		parts[i] = strconv.FormatFloat(c, 'f', 6, 64)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,369 @@
+// Package tiles proxies the map's basemap and radar tiles through the
+// dashboard's server. Tiles are kept in an on-disk cache, so browsers don't
+// each fetch them from third-party servers and the map keeps drawing from
+// the cache while an upstream is down. The basemap can also come from a
+// local MBTiles file, for screens with no internet access at all.
+package tiles
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/web"
+)
+
+// Radar defaults: the IEM NEXRAD base reflectivity composite. The time-
+// enabled endpoint serves past images, and is used when frames are asked
+// for without a URL.
+const (
+	DefaultRadarURL         = "https://mesonet.agron.iastate.edu/cgi-bin/wms/nexrad/n0q.cgi"
+	DefaultRadarLayers      = "nexrad-n0q-900913-conus"
+	DefaultRadarTimeURL     = "https://mesonet.agron.iastate.edu/cgi-bin/wms/nexrad/n0q-t.cgi"
+	DefaultRadarTimeLayers  = "nexrad-n0q-wmst"
+	DefaultRadarAttribution = "Radar data &copy; Iowa Environmental Mesonet"
+)
+
+// Options configure the tile proxy.
+type Options struct {
+	// Direct pages load tiles straight from their upstreams, bypassing the
+	// proxy, as pages written to disk always do.
+	Direct bool `yaml:"direct"`
+	// CacheDir holds fetched tiles. It defaults to warnings-dashboard/tiles
+	// in the user cache directory; "off" keeps no copies.
+	CacheDir string `yaml:"cacheDir"`
+	// MaxAge is how long a cached basemap tile is used before it is
+	// fetched again. Defaults to 30 days.
+	MaxAge time.Duration `yaml:"maxAge"`
+	// CacheSize caps the cache in megabytes, 500 by default. The least
+	// recently used tiles are removed beyond it.
+	CacheSize int `yaml:"cacheSize"`
+	// MaxZoom is the deepest zoom fetched from upstreams, 14 by default.
+	// The map scales up tiles of this zoom beyond it, so clients can't
+	// make the proxy fetch and store the billions of tiles at street level.
+	MaxZoom int `yaml:"maxZoom"`
+	// MBTiles is a raster MBTiles file served as the basemap instead of
+	// the upstream one.
+	MBTiles string       `yaml:"mbtiles"`
+	Radar   RadarOptions `yaml:"radar"`
+}
+
+// RadarOptions choose the radar overlay's upstream.
+type RadarOptions struct {
+	// URL is a WMS endpoint, or an XYZ tile template containing {z}, {x}
+	// and {y}, and {time} for timestamped frames.
+	URL string `yaml:"url"`
+	// Layers are the WMS layers to draw.
+	Layers      string `yaml:"layers"`
+	Attribution string `yaml:"attribution"`
+	// Frames is how many timestamped images the page animates through;
+	// zero shows only the latest image.
+	Frames int `yaml:"frames"`
+	// Interval is the time between frames, 5 minutes by default. Delay is
+	// how far behind the clock the newest frame is, so the upstream has
+	// finished producing it; it defaults to Interval.
+	Interval time.Duration `yaml:"interval"`
+	Delay    time.Duration `yaml:"delay"`
+	// TimeFormat is the Go layout of frame times sent upstream, as the WMS
+	// TIME parameter or in place of {time}. Defaults to RFC 3339 for WMS
+	// and 200601021504 for XYZ templates.
+	TimeFormat string `yaml:"timeFormat"`
+}
+
+// withDefaults fills in the IEM composite and the frame timing.
+func (o RadarOptions) withDefaults() RadarOptions {
+	if o.URL == "" {
+		if o.Frames > 0 {
+			o.URL, o.Layers = DefaultRadarTimeURL, DefaultRadarTimeLayers
+		} else {
+			o.URL, o.Layers = DefaultRadarURL, DefaultRadarLayers
+		}
+		if o.Attribution == "" {
+			o.Attribution = DefaultRadarAttribution
+		}
+	}
+	if o.Interval <= 0 {
+		o.Interval = 5 * time.Minute
+	}
+	if o.Delay <= 0 {
+		o.Delay = o.Interval
+	}
+	if o.TimeFormat == "" {
+		if o.xyz() {
+			o.TimeFormat = "200601021504"
+		} else {
+			o.TimeFormat = "2006-01-02T15:04:05Z"
+		}
+	}
+	return o
+}
+
+func (o RadarOptions) xyz() bool {
+	return strings.Contains(o.URL, "{z}")
+}
+
+// Layers returns the basemap and radar for a page that loads tiles from
+// their upstreams. Such a page shows only the latest radar image, since
+// choosing frame times is left to the proxy; a {time} template without the
+// proxy has no radar.
+func (o Options) Layers(basemap *web.Basemap) (*web.Basemap, *web.Radar) {
+	r := o.Radar
+	if r.URL == "" {
+		r.Frames = 0
+	}
+	r = r.withDefaults()
+	if strings.Contains(r.URL, "{time}") {
+		return basemap, nil
+	}
+	return basemap, &web.Radar{URL: r.URL, WMS: !r.xyz(), Layers: r.Layers, Attribution: r.Attribution}
+}
+
+// Proxy serves basemap tiles under basemap/ and radar tiles and frames
+// under radar/.
+type Proxy struct {
+	// Now is the clock radar frames are chosen by; time.Now when nil.
+	// Replayed sources set it so the radar matches the recorded event.
+	Now func() time.Time
+	// UserAgent is sent to upstream tile servers.
+	UserAgent string
+
+	radar   RadarOptions
+	basemap *web.Basemap
+	maxAge  time.Duration
+	maxZoom int
+	mbtiles *mbtiles
+	cache   *cache
+	// basemapKey and radarKey hash the upstream settings into cache keys,
+	// so changing an upstream doesn't serve the old one's tiles.
+	basemapKey, radarKey string
+
+	pruneMu   sync.Mutex
+	lastPrune time.Time
+}
+
+// New returns a proxy for opts. basemap is the upstream basemap, or nil
+// for none; an MBTiles file replaces it.
+func New(opts Options, basemap *web.Basemap) (*Proxy, error) {
+	p := &Proxy{
+		radar:   opts.Radar.withDefaults(),
+		basemap: basemap,
+		maxAge:  opts.MaxAge,
+		maxZoom: opts.MaxZoom,
+	}
+	if p.maxAge <= 0 {
+		p.maxAge = 30 * 24 * time.Hour
+	}
+	if p.maxZoom <= 0 {
+		p.maxZoom = 14
+	}
+	if basemap != nil {
+		p.basemapKey = upstreamKey(basemap.URL)
+	}
+	p.radarKey = upstreamKey(p.radar.URL, p.radar.Layers, p.radar.TimeFormat)
+	if opts.MBTiles != "" {
+		mb, err := openMBTiles(opts.MBTiles)
+		if err != nil {
+			return nil, err
+		}
+		p.mbtiles = mb
+	}
+
+	dir := opts.CacheDir
+	if dir == "" {
+		if base, err := os.UserCacheDir(); err == nil {
+			dir = filepath.Join(base, "warnings-dashboard", "tiles")
+		}
+	}
+	if dir != "" && dir != "off" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			log.Printf("[tiles] not caching tiles: %v", err)
+		} else {
+			size := opts.CacheSize
+			if size <= 0 {
+				size = 500
+			}
+			p.cache = newCache(dir, int64(size)<<20)
+		}
+	}
+	return p, nil
+}
+
+// Close closes the MBTiles file.
+func (p *Proxy) Close() error {
+	if p.mbtiles != nil {
+		return p.mbtiles.Close()
+	}
+	return nil
+}
+
+// Layers returns the basemap and radar for a page that loads tiles through
+// the proxy, at tiles/ relative to the page.
+func (p *Proxy) Layers() (*web.Basemap, *web.Radar) {
+	var basemap *web.Basemap
+	switch {
+	case p.mbtiles != nil:
+		m := p.mbtiles.meta
+		basemap = &web.Basemap{
+			URL:           "tiles/basemap/{z}/{x}/{y}." + m.format,
+			Attribution:   m.attribution,
+			MinZoom:       m.minZoom,
+			MaxNativeZoom: m.maxZoom,
+		}
+	case p.basemap != nil:
+		b := *p.basemap
+		b.URL = "tiles/basemap/{z}/{x}/{y}{r}.png"
+		b.MaxNativeZoom = p.maxZoom
+		basemap = &b
+	}
+
+	radar := &web.Radar{URL: "tiles/radar/latest/{z}/{x}/{y}.png", Attribution: p.radar.Attribution, MaxNativeZoom: p.maxZoom}
+	if p.radar.Frames > 0 {
+		radar.URL = "tiles/radar/{frame}/{z}/{x}/{y}.png"
+		radar.Frames = "tiles/radar/frames.json"
+	}
+	return basemap, radar
+}
+
+// ServeHTTP answers requests with the /tiles/ prefix already stripped.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "radar" && parts[1] == "frames.json":
+		p.serveFrames(w)
+	case len(parts) == 4 && parts[0] == "basemap":
+		t, ok := parseTile(parts[1:])
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		p.serveBasemap(w, t)
+	case len(parts) == 5 && parts[0] == "radar":
+		t, ok := parseTile(parts[2:])
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		p.serveRadar(w, parts[1], t)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// tile addresses an XYZ tile. Retina tiles are the @2x variant of the
+// upstream basemap.
+type tile struct {
+	z, x, y int
+	retina  bool
+}
+
+// parseTile reads z, x and y path segments; y may carry @2x and an
+// extension, as in 12@2x.png.
+func parseTile(parts []string) (tile, bool) {
+	y, _, _ := strings.Cut(parts[2], ".")
+	y, retina := strings.CutSuffix(y, "@2x")
+	var t tile
+	var err error
+	if t.z, err = strconv.Atoi(parts[0]); err != nil || t.z < 0 || t.z > 22 {
+		return tile{}, false
+	}
+	n := 1 << t.z
+	if t.x, err = strconv.Atoi(parts[1]); err != nil || t.x < 0 || t.x >= n {
+		return tile{}, false
+	}
+	if t.y, err = strconv.Atoi(y); err != nil || t.y < 0 || t.y >= n {
+		return tile{}, false
+	}
+	t.retina = retina
+	return t, true
+}
+
+func (t tile) String() string {
+	s := fmt.Sprintf("%d/%d/%d", t.z, t.x, t.y)
+	if t.retina {
+		s += "@2x"
+	}
+	return s
+}
+
+func (p *Proxy) serveBasemap(w http.ResponseWriter, t tile) {
+	if p.mbtiles != nil {
+		data, err := p.mbtiles.tile(t)
+		if err != nil {
+			log.Printf("[tiles] mbtiles %s: %v", t, err)
+			http.Error(w, "tile lookup failed", http.StatusInternalServerError)
+			return
+		}
+		if data == nil {
+			http.Error(w, "no such tile", http.StatusNotFound)
+			return
+		}
+		writeTile(w, data, p.maxAge)
+		return
+	}
+	if p.basemap == nil || t.z > p.maxZoom {
+		http.Error(w, "no such tile", http.StatusNotFound)
+		return
+	}
+	url := expand(p.basemap.URL, t, "")
+	p.serveUpstream(w, "basemap/"+p.basemapKey+"/"+t.String(), url, p.maxAge, p.maxAge)
+}
+
+// upstreamKey is a short hash of an upstream's settings.
+func upstreamKey(settings ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(settings, "\n")))
+	return hex.EncodeToString(sum[:4])
+}
+
+// serveUpstream writes the tile cached under key, fetching url when the
+// copy is older than maxAge. browserAge is the Cache-Control max-age.
+func (p *Proxy) serveUpstream(w http.ResponseWriter, key, url string, maxAge, browserAge time.Duration) {
+	data, err := p.fetch(key, url, maxAge)
+	if err != nil {
+		log.Printf("[tiles] %s: %v", key, err)
+		if errors.Is(err, errNotFound) {
+			http.Error(w, "no such tile", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "upstream tile server unavailable", http.StatusBadGateway)
+		return
+	}
+	writeTile(w, data, browserAge)
+}
+
+func writeTile(w http.ResponseWriter, data []byte, maxAge time.Duration) {
+	h := w.Header()
+	h.Set("Content-Type", http.DetectContentType(data))
+	h.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge/time.Second)))
+	w.Write(data)
+}
+
+// expand fills an XYZ template. {s} rotates through the a, b and c
+// subdomains most tile servers offer, and {r} is @2x for retina tiles.
+func expand(template string, t tile, when string) string {
+	r := ""
+	if t.retina {
+		r = "@2x"
+	}
+	return strings.NewReplacer(
+		"{s}", string(rune('a'+(t.x+t.y)%3)),
+		"{z}", strconv.Itoa(t.z),
+		"{x}", strconv.Itoa(t.x),
+		"{y}", strconv.Itoa(t.y),
+		"{r}", r,
+		"{time}", when,
+	).Replace(template)
+}
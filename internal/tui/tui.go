@@ -0,0 +1,663 @@
+// Package tui is a terminal version of the dashboard for use over SSH: a
+// live, colour-coded alert list grouped like the page, expiry countdowns, a
+// detail pane and keyboard filtering. It draws with plain ANSI escapes.
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Zachdehooge/warnings-dashboard/internal/fetcher"
+	"github.com/Zachdehooge/warnings-dashboard/internal/generator"
+	"golang.org/x/term"
+)
+
+// classColors are the dashboard's accent colours by severity class.
+var classColors = map[string][3]int{
+	"emergency":     {0xFF, 0x00, 0xFF},
+	"pds":           {0xFF, 0x6A, 0x00},
+	"tornado":       {0xFF, 0x14, 0x93},
+	"tstorm":        {0xFF, 0x00, 0x00},
+	"tornado-watch": {0xFF, 0xFF, 0x00},
+	"watch":         {0xFF, 0xA5, 0x00},
+	"severe":        {0xFF, 0x44, 0x44},
+	"moderate":      {0xFF, 0xAA, 0x00},
+	"sps":           {0x66, 0xB2, 0xFF},
+	"mcd":           {0x88, 0x66, 0xFF},
+}
+
+const (
+	reset   = "\x1b[0m"
+	bold    = "\x1b[1m"
+	dim     = "\x1b[2m"
+	reverse = "\x1b[7m"
+)
+
+// row is one line of the list: a group heading, an alert or an MCD.
+type row struct {
+	header string
+	class  string
+	alert  *fetcher.Warning
+	mcd    *generator.MesoscaleDiscussionJSON
+}
+
+func (r row) key() string {
+	switch {
+	case r.alert != nil:
+		return r.alert.ID
+	case r.mcd != nil:
+		return r.mcd.ID
+	}
+	return ""
+}
+
+type model struct {
+	payload *generator.PolledPayload
+	rows    []row
+	// failure is the error of the last poll cycle, until one succeeds.
+	failure  error
+	failedAt time.Time
+
+	selected  int
+	offset    int
+	filter    string
+	filtering bool
+	expanded  bool
+	scroll    int
+
+	width, height int
+}
+
+// Run draws the dashboard on the terminal until the user quits, redrawing
+// whenever payloads delivers a new poll cycle or failures a failed one, and
+// once a second for the countdowns.
+func Run(payloads <-chan *generator.PolledPayload, failures <-chan error) error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return errors.New("tui needs an interactive terminal")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("failed to set up terminal: %w", err)
+	}
+	defer term.Restore(fd, state)
+	// Alternate screen, hidden cursor; both undone on exit.
+	fmt.Fprint(os.Stdout, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(os.Stdout, "\x1b[?25h\x1b[?1049l")
+
+	keys := make(chan string, 16)
+	go readKeys(keys)
+
+	m := &model{}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		m.width, m.height, err = term.GetSize(int(os.Stdout.Fd()))
+		if err != nil || m.width < 20 || m.height < 10 {
+			m.width, m.height = 80, 24
+		}
+		m.rebuild()
+		os.Stdout.WriteString(m.render())
+
+		select {
+		case p := <-payloads:
+			m.payload = p
+			m.failure = nil
+		case err := <-failures:
+			m.failure, m.failedAt = err, time.Now()
+		case k := <-keys:
+			if !m.handleKey(k) {
+				return nil
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// now is the current time on the alert source's clock.
+func (m *model) now() time.Time {
+	if m.payload == nil {
+		return time.Now()
+	}
+	return time.Now().Add(time.Duration(m.payload.SourceOffset) * time.Second)
+}
+
+// rebuild regenerates the rows from the payload and filter, keeping the
+// selection on the same alert when it is still listed.
+func (m *model) rebuild() {
+	var prev string
+	if m.selected < len(m.rows) {
+		prev = m.rows[m.selected].key()
+	}
+
+	m.rows = nil
+	if m.payload != nil {
+		now := m.now()
+		var active []fetcher.Warning
+		for _, w := range m.payload.Warnings {
+			if exp, err := time.Parse(time.RFC3339, w.ExpiresTime); err == nil && !exp.After(now) {
+				continue
+			}
+			if m.matchAlert(w) {
+				active = append(active, w)
+			}
+		}
+		for _, g := range generator.GroupWarnings(active) {
+			m.rows = append(m.rows, row{header: fmt.Sprintf("%s (%d)", g.Type, len(g.Warnings)), class: g.Class})
+			for i := range g.Warnings {
+				m.rows = append(m.rows, row{class: g.Class, alert: &g.Warnings[i]})
+			}
+		}
+		var mcds []row
+		for i := range m.payload.MesoscaleDiscussions {
+			mcd := &m.payload.MesoscaleDiscussions[i]
+			if m.matchText(mcd.ID + " " + mcd.Name + " " + mcd.FullText) {
+				mcds = append(mcds, row{class: "mcd", mcd: mcd})
+			}
+		}
+		if len(mcds) > 0 {
+			m.rows = append(m.rows, row{header: fmt.Sprintf("Mesoscale Discussions (%d)", len(mcds)), class: "mcd"})
+			m.rows = append(m.rows, mcds...)
+		}
+	}
+
+	m.selected = -1
+	for i, r := range m.rows {
+		if r.header != "" {
+			continue
+		}
+		if m.selected < 0 || r.key() == prev {
+			m.selected = i
+		}
+		if r.key() == prev {
+			break
+		}
+	}
+	if m.selected < 0 {
+		m.selected = 0
+	}
+}
+
+func (m *model) matchAlert(w fetcher.Warning) bool {
+	return m.matchText(strings.Join([]string{w.DisplayType(), w.Area, w.Severity, strings.Join(w.UGC, " ")}, " "))
+}
+
+// matchText reports whether every word of the filter appears in s.
+func (m *model) matchText(s string) bool {
+	s = strings.ToLower(s)
+	for _, word := range strings.Fields(strings.ToLower(m.filter)) {
+		if !strings.Contains(s, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// handleKey applies a key press and reports whether to keep running.
+func (m *model) handleKey(k string) bool {
+	if k == "ctrl+c" {
+		return false
+	}
+	if m.filtering {
+		switch k {
+		case "enter":
+			m.filtering = false
+		case "esc":
+			m.filtering, m.filter = false, ""
+		case "backspace":
+			if r := []rune(m.filter); len(r) > 0 {
+				m.filter = string(r[:len(r)-1])
+			}
+		default:
+			if len([]rune(k)) == 1 {
+				m.filter += k
+			}
+		}
+		return true
+	}
+
+	page := m.listHeight() - 1
+	switch k {
+	case "q":
+		return false
+	case "/":
+		m.filtering, m.expanded = true, false
+	case "esc":
+		if m.expanded {
+			m.expanded = false
+		} else {
+			m.filter = ""
+		}
+	case "enter", "tab":
+		m.expanded, m.scroll = !m.expanded, 0
+	case "up", "k":
+		if m.expanded {
+			m.scroll = max(m.scroll-1, 0)
+		} else {
+			m.move(-1)
+		}
+	case "down", "j":
+		if m.expanded {
+			m.scroll++
+		} else {
+			m.move(1)
+		}
+	case "pgup":
+		if m.expanded {
+			m.scroll = max(m.scroll-page, 0)
+		} else {
+			m.move(-page)
+		}
+	case "pgdn", " ":
+		if m.expanded {
+			m.scroll += page
+		} else {
+			m.move(page)
+		}
+	case "home", "g":
+		m.move(-len(m.rows))
+	case "end", "G":
+		m.move(len(m.rows))
+	}
+	return true
+}
+
+// move shifts the selection by n alerts, skipping group headings.
+func (m *model) move(n int) {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for ; n > 0; n-- {
+		i := m.selected + step
+		for i >= 0 && i < len(m.rows) && m.rows[i].header != "" {
+			i += step
+		}
+		if i < 0 || i >= len(m.rows) {
+			break
+		}
+		m.selected = i
+	}
+	m.scroll = 0
+}
+
+func (m *model) detailHeight() int {
+	return max(m.height/3, 6)
+}
+
+func (m *model) listHeight() int {
+	// Title, summary, separator and status lines.
+	return m.height - m.detailHeight() - 4
+}
+
+func (m *model) render() string {
+	var lines []string
+	lines = append(lines, m.titleLine(), m.summaryLine())
+
+	if m.expanded {
+		body := m.detailLines()
+		h := m.height - 3
+		m.scroll = min(m.scroll, max(len(body)-h, 0))
+		for i := 0; i < h; i++ {
+			if m.scroll+i < len(body) {
+				lines = append(lines, body[m.scroll+i])
+			} else {
+				lines = append(lines, "")
+			}
+		}
+	} else {
+		h := m.listHeight()
+		if m.selected < m.offset {
+			m.offset = m.selected
+		} else if m.selected >= m.offset+h {
+			m.offset = m.selected - h + 1
+		}
+		// Show the group heading of the first visible alert.
+		if m.offset > 0 && m.offset < len(m.rows) && m.rows[m.offset-1].header != "" && m.selected-m.offset+1 < h {
+			m.offset--
+		}
+		m.offset = max(min(m.offset, len(m.rows)-h), 0)
+		for i := 0; i < h; i++ {
+			if m.offset+i < len(m.rows) {
+				lines = append(lines, m.rowLine(m.offset+i))
+			} else if i == 0 && m.payload == nil {
+				lines = append(lines, dim+"  Fetching alerts..."+reset)
+			} else if i == 0 {
+				lines = append(lines, dim+"  No active alerts"+matchNote(m.filter)+reset)
+			} else {
+				lines = append(lines, "")
+			}
+		}
+		lines = append(lines, dim+strings.Repeat("─", m.width)+reset)
+		body := m.detailLines()
+		for i := 0; i < m.detailHeight(); i++ {
+			if i < len(body) {
+				lines = append(lines, body[i])
+			} else {
+				lines = append(lines, "")
+			}
+		}
+	}
+	lines = append(lines, m.statusLine())
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for i, l := range lines {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(l)
+		b.WriteString(reset + "\x1b[K")
+	}
+	b.WriteString("\x1b[J")
+	return b.String()
+}
+
+func matchNote(filter string) string {
+	if filter == "" {
+		return ""
+	}
+	return " matching \"" + filter + "\""
+}
+
+func (m *model) titleLine() string {
+	left := " US Weather Warnings"
+	right := ""
+	if m.payload != nil {
+		right = fmt.Sprintf("%d alerts · %d MCDs · updated %s ", len(m.payload.Warnings),
+			len(m.payload.MesoscaleDiscussions), time.Unix(m.payload.UpdatedAtUTC, 0).Local().Format("15:04:05"))
+	}
+	gap := max(m.width-len([]rune(left))-len([]rune(right)), 1)
+	return reverse + bold + fit(left+strings.Repeat(" ", gap)+right, m.width)
+}
+
+// summaryLine counts alerts like the page's status bar.
+func (m *model) summaryLine() string {
+	if m.payload == nil {
+		return ""
+	}
+	counts := map[string]int{}
+	for _, w := range m.payload.Warnings {
+		t := strings.ToLower(w.Type)
+		switch {
+		case w.Tier != "":
+			counts[w.Tier]++
+		case strings.Contains(t, "tornado warning"):
+			counts["tornado"]++
+		case strings.Contains(t, "thunderstorm warning"):
+			counts["tstorm"]++
+		case strings.Contains(t, "tornado watch"):
+			counts["tornado-watch"]++
+		case strings.Contains(t, "thunderstorm watch"):
+			counts["watch"]++
+		case strings.Contains(t, "special weather statement"):
+			counts["sps"]++
+		}
+	}
+	counts["mcd"] = len(m.payload.MesoscaleDiscussions)
+
+	var parts []string
+	for _, item := range []struct{ class, label string }{
+		{"emergency", "Emergency"}, {"pds", "PDS"}, {"tornado", "Tornado"}, {"tstorm", "T-Storm"},
+		{"tornado-watch", "Tor Watch"}, {"watch", "T-Storm Watch"}, {"sps", "SWS"}, {"mcd", "MCDs"},
+	} {
+		n := counts[item.class]
+		if n == 0 && (item.class == "emergency" || item.class == "pds") {
+			continue
+		}
+		parts = append(parts, color(item.class)+fmt.Sprintf("%s %d", item.label, n)+reset)
+	}
+	return " " + strings.Join(parts, "  ")
+}
+
+func (m *model) rowLine(i int) string {
+	r := m.rows[i]
+	if r.header != "" {
+		return bold + color(r.class) + fit(" ▌"+strings.ToUpper(r.header), m.width)
+	}
+
+	var text string
+	if r.alert != nil {
+		w := r.alert
+		text = fmt.Sprintf("   %-30s %-8s %12s  %s", fit(w.DisplayType(), 30), fit(w.Severity, 8),
+			countdown(w.ExpiresTime, m.now()), w.Area)
+	} else {
+		text = fmt.Sprintf("   %-30s %s", r.mcd.ID, mcdConcerning(*r.mcd))
+	}
+	text = fit(text, m.width)
+	if i == m.selected {
+		return reverse + color(r.class) + text
+	}
+	return color(r.class) + text
+}
+
+func (m *model) statusLine() string {
+	if m.filtering {
+		return bold + " Filter: " + reset + m.filter + reverse + " " + reset
+	}
+	help := " ↑↓ move  enter details  / filter  q quit"
+	if m.expanded {
+		help = " ↑↓ scroll  enter/esc back  q quit"
+	}
+	if m.filter != "" {
+		help += "   filter: " + m.filter + " (esc clears)"
+	}
+	if m.failure != nil {
+		// The failure replaces the key help. Errors wrap their causes, so a
+		// long one is cut from the front to keep the root cause visible.
+		prefix := fmt.Sprintf(" Poll failed at %s, retrying: ", m.failedAt.Format("15:04:05"))
+		msg := []rune(m.failure.Error())
+		if room := m.width - len([]rune(prefix)); len(msg) > room && room > 1 {
+			msg = append([]rune("…"), msg[len(msg)-room+1:]...)
+		}
+		return bold + color("tstorm") + fit(prefix+string(msg), m.width)
+	}
+	return dim + fit(help, m.width)
+}
+
+// detailLines describes the selected alert or MCD, wrapped to the width.
+func (m *model) detailLines() []string {
+	if m.selected >= len(m.rows) {
+		return nil
+	}
+	r := m.rows[m.selected]
+	width := m.width - 2
+	var out []string
+	add := func(style, s string) {
+		for _, l := range wrap(s, width) {
+			out = append(out, style+" "+l+reset)
+		}
+	}
+
+	switch {
+	case r.alert != nil:
+		w := r.alert
+		add(bold+color(r.class), w.DisplayType()+" — "+w.Area)
+		add("", fmt.Sprintf("%s · %s · %s", w.Severity, w.Certainty, w.Urgency))
+		add(dim, "Issued "+localTime(w.Time)+"   Expires "+localTime(w.ExpiresTime)+" ("+countdown(w.ExpiresTime, m.now())+")")
+		if tags := hazardTags(*w); tags != "" {
+			add(bold, tags)
+		}
+		if w.SenderName != "" {
+			add(dim, w.SenderName)
+		}
+		if w.Headline != "" {
+			out = append(out, "")
+			add(bold, w.Headline)
+		}
+		out = append(out, "")
+		add("", w.Description)
+		if w.Instruction != "" {
+			out = append(out, "")
+			add("", "Instructions: "+w.Instruction)
+		}
+	case r.mcd != nil:
+		add(bold+color("mcd"), r.mcd.ID+" — "+mcdConcerning(*r.mcd))
+		out = append(out, "")
+		add("", r.mcd.FullText)
+	}
+	return out
+}
+
+// mcdConcerning returns the "Concerning..." line of an MCD, or its name.
+func mcdConcerning(mcd generator.MesoscaleDiscussionJSON) string {
+	for _, l := range strings.Split(mcd.FullText, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(l), "Concerning...") {
+			return strings.TrimPrefix(strings.TrimSpace(l), "Concerning...")
+		}
+	}
+	return mcd.Name
+}
+
+func hazardTags(w fetcher.Warning) string {
+	var tags []string
+	if w.TornadoDetection != "" {
+		tags = append(tags, "Tornado "+strings.ToLower(w.TornadoDetection))
+	}
+	if w.TornadoDamageThreat != "" {
+		tags = append(tags, strings.ToLower(w.TornadoDamageThreat)+" tornado damage")
+	}
+	if w.ThunderstormDamageThreat != "" {
+		tags = append(tags, strings.ToLower(w.ThunderstormDamageThreat)+" damage")
+	}
+	if w.MaxHailSize > 0 {
+		tags = append(tags, fmt.Sprintf(`Hail %.2f"`, w.MaxHailSize))
+	}
+	if w.MaxWindGust > 0 {
+		tags = append(tags, fmt.Sprintf("Wind %d mph", w.MaxWindGust))
+	}
+	return strings.ToUpper(strings.Join(tags, " · "))
+}
+
+// countdown formats the time until expires like the page does: 1h 5m 3s.
+func countdown(expires string, now time.Time) string {
+	t, err := time.Parse(time.RFC3339, expires)
+	if err != nil {
+		return "-"
+	}
+	left := int(t.Sub(now).Seconds())
+	if left <= 0 {
+		return "expired"
+	}
+	h, mins, s := left/3600, left%3600/60, left%60
+	if h > 0 {
+		return fmt.Sprintf("%dh %dm %ds", h, mins, s)
+	}
+	return fmt.Sprintf("%dm %ds", mins, s)
+}
+
+func localTime(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return "-"
+	}
+	return t.Local().Format("Jan 2 3:04 PM MST")
+}
+
+func color(class string) string {
+	c, ok := classColors[class]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", c[0], c[1], c[2])
+}
+
+// fit pads or cuts s to exactly n columns.
+func fit(s string, n int) string {
+	r := []rune(s)
+	if len(r) > n {
+		return string(r[:n])
+	}
+	return s + strings.Repeat(" ", n-len(r))
+}
+
+// wrap breaks text into lines of at most width columns at spaces, keeping
+// its own line breaks.
+func wrap(text string, width int) []string {
+	var out []string
+	for _, para := range strings.Split(strings.ReplaceAll(text, "\r", ""), "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			for len([]rune(word)) > width {
+				if line != "" {
+					out = append(out, line)
+					line = ""
+				}
+				out = append(out, string([]rune(word)[:width]))
+				word = string([]rune(word)[width:])
+			}
+			switch {
+			case line == "":
+				line = word
+			case len([]rune(line))+1+len([]rune(word)) <= width:
+				line += " " + word
+			default:
+				out = append(out, line)
+				line = word
+			}
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+// readKeys turns terminal input into key names: arrows and paging keys by
+// name, control keys such as "enter" and "esc", and other characters as
+// themselves.
+func readKeys(keys chan<- string) {
+	buf := make([]byte, 64)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			keys <- "ctrl+c"
+			return
+		}
+		in := string(buf[:n])
+		for len(in) > 0 {
+			key, size := nextKey(in)
+			in = in[size:]
+			if key != "" {
+				keys <- key
+			}
+		}
+	}
+}
+
+var escapes = []struct{ seq, key string }{
+	{"\x1b[A", "up"}, {"\x1bOA", "up"}, {"\x1b[B", "down"}, {"\x1bOB", "down"},
+	{"\x1b[5~", "pgup"}, {"\x1b[6~", "pgdn"},
+	{"\x1b[H", "home"}, {"\x1b[1~", "home"}, {"\x1b[F", "end"}, {"\x1b[4~", "end"},
+}
+
+func nextKey(in string) (string, int) {
+	if in[0] == 0x1b {
+		for _, e := range escapes {
+			if strings.HasPrefix(in, e.seq) {
+				return e.key, len(e.seq)
+			}
+		}
+		if len(in) == 1 {
+			return "esc", 1
+		}
+		// An unknown sequence: drop the rest of the read.
+		return "", len(in)
+	}
+	switch in[0] {
+	case 0x03:
+		return "ctrl+c", 1
+	case '\r', '\n':
+		return "enter", 1
+	case '\t':
+		return "tab", 1
+	case 0x7f, 0x08:
+		return "backspace", 1
+	}
+	if in[0] < 0x20 {
+		return "", 1
+	}
+	r := []rune(in)[0]
+	return string(r), len(string(r))
+}
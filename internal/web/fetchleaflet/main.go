@@ -0,0 +1,62 @@
+// Command fetchleaflet downloads the Leaflet distribution into the web
+// package's static/leaflet, so the binary serves Leaflet itself instead of
+// loading it from unpkg.com. Run it with go generate ./internal/web.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// files are the parts of the dist directory the page needs; leaflet.css
+// refers to the images by relative path.
+var files = []string{
+	"leaflet.js",
+	"leaflet.css",
+	"images/layers.png",
+	"images/layers-2x.png",
+	"images/marker-icon.png",
+	"images/marker-icon-2x.png",
+	"images/marker-shadow.png",
+}
+
+func main() {
+	var (
+		out  = flag.String("o", "static/leaflet", "Output directory")
+		base = flag.String("url", "https://unpkg.com/leaflet@1.9.4/dist/", "Leaflet dist URL")
+	)
+	flag.Parse()
+
+	client := &http.Client{Timeout: time.Minute}
+	for _, name := range files {
+		if err := fetch(client, *base+name, filepath.Join(*out, filepath.FromSlash(name))); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("fetched %s", name)
+	}
+}
+
+func fetch(client *http.Client, url, path string) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", url, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
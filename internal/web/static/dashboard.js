@@ -0,0 +1,1270 @@
+// dashboard.js drives the warnings page: the map, the alert list, live
+// updates from /events and warnings.json, and browser notifications. The
+// server passes its initial state in the DASHBOARD object.
+let map;
+let radarLayer;
+let warningsData = DASHBOARD.warnings;
+// serverWarnings is the server's alert set before local expiry pruning.
+let serverWarnings = warningsData.slice();
+let lastSeq = null;
+let streamOpen = false;
+let warningLayers = [];
+let mesoscaleDiscussions = [];
+let validMCDs = [];
+let lifecycleEvents = [];
+let flashUntil = {};
+let seenEventKeys = null;
+ let lastUpdateTime = Date.now();
+ let clockOffset = DASHBOARD.sourceOffset * 1000;
+ const initialBounds = DASHBOARD.initialBounds;
+
+ // serverNow is the current time on the alert source's clock, which
+ // differs from Date.now() only when the server is replaying an old event.
+ function serverNow() {
+    return Date.now() + clockOffset;
+ }
+
+ function clearRadarLayer() {
+     if (radarLayer && map.hasLayer(radarLayer)) {
+        map.removeLayer(radarLayer);
+     }
+  }
+
+  function addRadarLayer() {
+     if (radarLayer) {
+        if (!map.hasLayer(radarLayer)) {
+           radarLayer.addTo(map);
+        }
+        radarLayer.bringToBack();
+     }
+  }
+
+ async function fetchUpdatedWarnings() {
+   try {
+      // no-cache revalidates with If-None-Match, so an unchanged payload is a 304.
+      const response = await fetch('warnings.json', { cache: 'no-cache' });
+      if (!response.ok) throw new Error('Failed to read warnings.json: ' + response.status);
+
+      const payload = await response.json();
+      console.log('[poll] ' + (payload.warnings || []).length + ' warnings from warnings.json, updated ' + payload.lastUpdated);
+
+      lastSeq = payload.seq || 0;
+      serverWarnings = payload.warnings || [];
+      mesoscaleDiscussions = toMCDFeatures(payload.mesoscaleDiscussions || []);
+      console.log('[poll] MCDs from server: ' + mesoscaleDiscussions.length);
+      renderServerState(payload);
+   } catch (error) {
+      console.error('[poll] error reading warnings.json:', error);
+   }
+}
+
+// applyDelta merges a pushed change set into the alerts from the last
+// full read. A gap in sequence numbers means a delta was missed, so the
+// full payload is re-read instead.
+function applyDelta(delta) {
+   if (lastSeq !== null && delta.seq <= lastSeq) return;
+   if (lastSeq === null || delta.seq !== lastSeq + 1) {
+      console.log('[stream] missed delta before ' + delta.seq + ', re-reading warnings.json');
+      fetchUpdatedWarnings();
+      return;
+   }
+   lastSeq = delta.seq;
+
+   const removed = new Set(delta.removed || []);
+   const changed = {};
+   (delta.added || []).concat(delta.updated || []).forEach(w => { changed[w.id] = w; });
+   serverWarnings = serverWarnings
+      .filter(w => !removed.has(w.id) && !changed[w.id])
+      .concat(Object.values(changed));
+   if (delta.mesoscaleDiscussions) {
+      mesoscaleDiscussions = toMCDFeatures(delta.mesoscaleDiscussions);
+   }
+   console.log('[stream] delta ' + delta.seq + ': +' + (delta.added || []).length +
+      ' ~' + (delta.updated || []).length + ' -' + removed.size);
+   renderServerState(delta);
+}
+
+function toMCDFeatures(mcds) {
+   return mcds.map(mcd => ({
+      type: 'Feature',
+      geometry: mcd.geometry,
+      properties: {
+         name: mcd.name,
+         folderpath: '',
+         popupinfo: mcd.popupInfo,
+         idp_filedate: mcd.idp_filedate,
+         _fullText: mcd.fullText || ''
+      }
+   }));
+}
+
+// renderServerState redraws the map and list from serverWarnings and
+// the metadata of a payload or delta.
+function renderServerState(meta) {
+   clockOffset = (meta.sourceOffset || 0) * 1000;
+   applyLifecycleEvents(meta.events || []);
+   renderLocations(meta.locations, meta.locationEvents);
+   const now = serverNow();
+   warningsData = serverWarnings.filter(w =>
+      !w.expiresTime || new Date(w.expiresTime).getTime() > now
+   );
+   checkNewAlerts(warningsData);
+
+   lastUpdateTime = Date.now();
+
+   clearWarningLayers();
+   addMesoscaleDiscussionsToMap();
+   addWarningsToMap();
+   bringSevereToFront();
+   addMesoscaleDiscussionsToList();
+   updateListView(warningsData);
+   updateStats({ counter: warningsData.length, updatedAtUTC: meta.updatedAtUTC });
+   console.log('[poll] map and list updated with ' + warningsData.length + ' warnings');
+}
+
+// renderLocations fills the "My locations" panel, which stays hidden
+// unless locations are configured on the server.
+function renderLocations(locations, events) {
+   const section = document.getElementById('my-locations');
+   if (!section) return;
+   if (!locations || locations.length === 0) {
+      section.style.display = 'none';
+      return;
+   }
+   section.style.display = '';
+
+   document.getElementById('location-cards').innerHTML = locations.map(loc => {
+      const state = loc.warned ? 'warned' : (loc.covered ? 'covered' : '');
+      const label = loc.warned ? 'Warned' : (loc.covered ? 'Watch / advisory' : 'Clear');
+      const alerts = (loc.alerts || []).map(a =>
+         '<div class="location-alert" onclick="zoomToWarning(\'' + a.id + '\')">' +
+         escapeHtml(getDisplayType(a)) + '</div>'
+      ).join('');
+      return '<div class="location-card ' + state + '">' +
+         '<span class="location-state">' + label + '</span>' +
+         '<div class="location-name">' + escapeHtml(loc.name) + '</div>' + alerts +
+      '</div>';
+   }).join('');
+
+   document.getElementById('location-events').innerHTML = (events || []).slice(0, 5).map(e =>
+      '<div>' + parseISOTime(e.at) + ' — ' + escapeHtml(e.location) +
+      (e.kind === 'enter' ? ' entered ' : ' left ') + escapeHtml(e.type) + '</div>'
+   ).join('');
+}
+
+// connectStream subscribes to pushed deltas. While the stream is open
+// the timed poll is skipped; if the server has no stream (a plain file
+// host) or it drops, polling carries on as before.
+function connectStream() {
+   if (!window.EventSource) return;
+   const source = new EventSource('events');
+   source.onopen = function() {
+      console.log('[stream] connected');
+      streamOpen = true;
+      // Resynchronise: deltas may have been missed while disconnected.
+      fetchUpdatedWarnings();
+   };
+   source.addEventListener('delta', function(e) {
+      applyDelta(JSON.parse(e.data));
+   });
+   source.onerror = function() {
+      if (streamOpen) console.log('[stream] disconnected, polling until it reconnects');
+      streamOpen = false;
+   };
+}
+
+// applyLifecycleEvents flashes alerts that are new, updated or extended
+// since the last poll. Events already seen on an earlier poll are
+// ignored, and nothing flashes on the first load.
+function applyLifecycleEvents(events) {
+   lifecycleEvents = events;
+   const keys = new Set(events.map(e => e.kind + ':' + e.id));
+   if (seenEventKeys) {
+      const until = Date.now() + 30000;
+      events.forEach(e => {
+         if (seenEventKeys.has(e.kind + ':' + e.id)) return;
+         if (e.kind === 'new' || e.kind === 'updated' || e.kind === 'extended') flashUntil[e.id] = until;
+      });
+   }
+   seenEventKeys = keys;
+}
+
+// Alert notifications are opt-in and configured per browser. Preferences
+// live in localStorage; an empty states list means every state.
+const alertPrefTypes = ['Tornado Warning', 'Severe Thunderstorm Warning', 'Tornado Watch',
+   'Severe Thunderstorm Watch', 'Flash Flood Warning', 'Special Weather Statement'];
+const defaultAlertPrefs = { notify: false, sound: false, types: ['Tornado Warning'], states: [], quietStart: '', quietEnd: '' };
+let alertPrefs = loadAlertPrefs();
+let knownAlertIds = null;
+let audioCtx = null;
+
+function loadAlertPrefs() {
+   try {
+      return Object.assign({}, defaultAlertPrefs, JSON.parse(localStorage.getItem('alertPrefs') || '{}'));
+   } catch (e) {
+      return Object.assign({}, defaultAlertPrefs);
+   }
+}
+
+function saveAlertPrefs() {
+   localStorage.setItem('alertPrefs', JSON.stringify(alertPrefs));
+   document.getElementById('alert-settings-toggle').classList.toggle('on', alertPrefs.notify || alertPrefs.sound);
+}
+
+function renderAlertSettings() {
+   document.getElementById('pref-notify').checked = alertPrefs.notify;
+   document.getElementById('pref-sound').checked = alertPrefs.sound;
+   document.getElementById('pref-types').innerHTML = alertPrefTypes.map(t =>
+      '<label><input type="checkbox" value="' + t + '"' + (alertPrefs.types.includes(t) ? ' checked' : '') +
+      ' onchange="readAlertSettings()"> ' + t + '</label>'
+   ).join('');
+   document.getElementById('pref-states').value = alertPrefs.states.join(', ');
+   document.getElementById('pref-quiet-start').value = alertPrefs.quietStart;
+   document.getElementById('pref-quiet-end').value = alertPrefs.quietEnd;
+   document.getElementById('alert-settings-toggle').classList.toggle('on', alertPrefs.notify || alertPrefs.sound);
+}
+
+function toggleAlertSettings() {
+   const panel = document.getElementById('alert-settings');
+   panel.style.display = panel.style.display === 'none' ? '' : 'none';
+}
+
+// readAlertSettings saves the settings form. Turning notifications on
+// asks for permission, and turning sound on unlocks audio, both of
+// which browsers only allow in response to a click.
+function readAlertSettings() {
+   alertPrefs.notify = document.getElementById('pref-notify').checked;
+   alertPrefs.sound = document.getElementById('pref-sound').checked;
+   alertPrefs.types = Array.from(document.querySelectorAll('#pref-types input:checked')).map(el => el.value);
+   alertPrefs.states = document.getElementById('pref-states').value.split(/[\s,]+/)
+      .map(st => st.trim().toUpperCase()).filter(st => st.length === 2);
+   alertPrefs.quietStart = document.getElementById('pref-quiet-start').value;
+   alertPrefs.quietEnd = document.getElementById('pref-quiet-end').value;
+   if (alertPrefs.notify && window.Notification && Notification.permission === 'default') {
+      Notification.requestPermission();
+   }
+   if (alertPrefs.sound) unlockAudio();
+   saveAlertPrefs();
+}
+
+function unlockAudio() {
+   const Ctx = window.AudioContext || window.webkitAudioContext;
+   if (!Ctx) return;
+   if (!audioCtx) audioCtx = new Ctx();
+   if (audioCtx.state === 'suspended') audioCtx.resume();
+}
+
+// inQuietHours reports whether the local time falls within the quiet
+// hours, which may wrap past midnight.
+function inQuietHours() {
+   if (!alertPrefs.quietStart || !alertPrefs.quietEnd) return false;
+   const d = new Date();
+   const now = String(d.getHours()).padStart(2, '0') + ':' + String(d.getMinutes()).padStart(2, '0');
+   const start = alertPrefs.quietStart, end = alertPrefs.quietEnd;
+   return start <= end ? (now >= start && now < end) : (now >= start || now < end);
+}
+
+function wantsAlert(w) {
+   if (!alertPrefs.types.includes(w.type)) return false;
+   if (alertPrefs.states.length === 0) return true;
+   return (w.ugc || []).some(code => alertPrefs.states.includes(code.slice(0, 2).toUpperCase()));
+}
+
+// checkNewAlerts notifies about alert IDs not seen on the previous
+// read. Nothing fires on the first load. Quiet hours silence everything
+// except emergencies.
+function checkNewAlerts(warnings) {
+   const ids = new Set(warnings.map(w => w.id));
+   const previous = knownAlertIds;
+   knownAlertIds = ids;
+   if (!previous || (!alertPrefs.notify && !alertPrefs.sound)) return;
+
+   const fresh = warnings.filter(w => !previous.has(w.id) && wantsAlert(w))
+      .filter(w => w.tier === 'emergency' || !inQuietHours());
+   if (fresh.length === 0) return;
+   console.log('[alerts] ' + fresh.length + ' new alert(s) of a selected type');
+   if (alertPrefs.sound) playAlertTone(fresh.some(w => w.tier === 'emergency' || w.tier === 'pds'));
+   if (alertPrefs.notify) fresh.forEach(showAlertNotification);
+}
+
+function showAlertNotification(w) {
+   if (!window.Notification || Notification.permission !== 'granted') return;
+   const n = new Notification(getDisplayType(w), {
+      body: (w.area || '') + (w.expiresTime ? '\nExpires ' + parseISOTime(w.expiresTime) : ''),
+      tag: w.id,
+      requireInteraction: w.tier === 'emergency'
+   });
+   n.onclick = function() {
+      window.focus();
+      zoomToWarning(w.id);
+      n.close();
+   };
+}
+
+// playAlertTone beeps three times, or sounds an alternating two-tone
+// alarm for emergencies and PDS alerts.
+function playAlertTone(urgent) {
+   unlockAudio();
+   if (!audioCtx) return;
+   const start = audioCtx.currentTime;
+   const beeps = urgent ? 8 : 3;
+   for (let i = 0; i < beeps; i++) {
+      const osc = audioCtx.createOscillator();
+      const gain = audioCtx.createGain();
+      const t = start + i * (urgent ? 0.25 : 0.4);
+      osc.frequency.value = urgent ? (i % 2 ? 960 : 853) : 880;
+      gain.gain.setValueAtTime(0.2, t);
+      gain.gain.setValueAtTime(0, t + (urgent ? 0.24 : 0.2));
+      osc.connect(gain).connect(audioCtx.destination);
+      osc.start(t);
+      osc.stop(t + 0.25);
+   }
+}
+
+function testAlertTone() {
+   playAlertTone(false);
+   if (alertPrefs.notify && window.Notification && Notification.permission === 'granted') {
+      new Notification('Test alert', { body: 'Notifications are working.' });
+   }
+}
+
+function renderLifecycleBadge(w) {
+   const labels = { new: 'New', updated: 'Updated', extended: 'Extended' };
+   const label = labels[w.lifecycle];
+   return label ? '<span class="lifecycle-badge ' + w.lifecycle + '">' + label + '</span>' : '';
+}
+
+function renderEndedList() {
+   const ended = lifecycleEvents.filter(e => e.kind === 'cancelled' || e.kind === 'expired');
+   if (ended.length === 0) return '';
+   let html = '<div class="warning-type-header ended"><h2>Recently Ended (' + ended.length + ')</h2></div>';
+   ended.forEach(e => {
+      const label = e.kind === 'cancelled' ? (e.early ? 'Cancelled early' : 'Cancelled') : 'Expired';
+      html += '<div class="warning-card ended">' +
+         '<div class="warning-card-header"><h3>' + escapeHtml(e.type||'') + '</h3>' +
+         '<span class="severity-badge">' + label + '</span></div>' +
+         '<div class="area">' + escapeHtml(e.area||'') + '</div>' +
+         '<div class="times"><span>Ended: ' + parseISOTime(e.at) + '</span></div>' +
+      '</div>';
+   });
+   return html;
+}
+
+function stripZ(geometry) {
+   if (!geometry) return geometry;
+   const strip2D = coords => coords.map(c => [c[0], c[1]]);
+   switch (geometry.type) {
+      case 'Polygon':     return { type: 'Polygon',     coordinates: geometry.coordinates.map(strip2D) };
+      case 'MultiPolygon':return { type: 'MultiPolygon', coordinates: geometry.coordinates.map(ring => ring.map(strip2D)) };
+      default: return geometry;
+   }
+}
+
+function extractMCDNumber(props) {
+   if (!props.name) return '????';
+   const digits = String(props.name).replace(/[^0-9]/g, '');
+   return digits ? digits : '????';
+}
+
+function formatArcGISDate(msTimestamp) {
+   if (!msTimestamp && msTimestamp !== 0) return 'Not specified';
+   return new Date(msTimestamp).toLocaleString(undefined, {
+      year:'numeric', month:'short', day:'numeric',
+      hour:'numeric', minute:'2-digit', second:'2-digit', timeZoneName:'short'
+   });
+}
+
+function extractExpireTime(validStr) {
+   if (!validStr) return '';
+   const match = validStr.match(/-\s*(\d{6}Z)/);
+   return match ? match[1] : '';
+}
+
+function formatExpireToLocal(utcStr, rawText) {
+   if (!utcStr || utcStr.length !== 7) return utcStr;
+   const day = parseInt(utcStr.substring(0,2));
+   const hour = parseInt(utcStr.substring(2,4));
+   const min = parseInt(utcStr.substring(4,6));
+   let month = new Date().getUTCMonth(), year = new Date().getUTCFullYear();
+   if (rawText) {
+      const mm = rawText.match(/\s+(Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)\s+\d{1,2}\s+\d{4}/i);
+      if (mm) {
+         const months = {'Jan':0,'Feb':1,'Mar':2,'Apr':3,'May':4,'Jun':5,'Jul':6,'Aug':7,'Sep':8,'Oct':9,'Nov':10,'Dec':11};
+         month = months[mm[1]];
+         const ym = rawText.match(/[A-Z][a-z]{2}\s+[A-Z][a-z]{2}\s+(\d{4})/);
+         if (ym) year = parseInt(ym[1]);
+      }
+   }
+   return new Date(Date.UTC(year, month, day, hour, min)).toLocaleString(undefined, {
+      year:'numeric', month:'short', day:'numeric', hour:'numeric', minute:'2-digit', timeZoneName:'short'
+   });
+}
+
+function mcdSPCLink(mcdNum) {
+   return 'https://www.spc.noaa.gov/products/md/' + new Date().getFullYear() + '/md' + mcdNum + '.html';
+}
+
+function parseMCDText(rawText) {
+   if (!rawText) return { area:'', concerning:'', valid:'', summary:'', discussion:'', probability:'', raw:'' };
+   const startIdx = rawText.indexOf('MESOSCALE DISCUSSION');
+   const text = startIdx >= 0 ? rawText.substring(startIdx) : rawText;
+   const getInline = (label) => {
+      const m = text.match(new RegExp(label + '\\.{3}(.+)', 'i'));
+      if (m) return m[1].trim();
+      const m2 = text.match(new RegExp(label + '\\s+(.+)', 'i'));
+      return m2 ? m2[1].trim() : '';
+   };
+   const getBlock = (label) => {
+      const m = text.match(new RegExp(label + '\\.{3}[\\s\\S]*?(?=\\n[A-Z][A-Z .]{2,}\\.{3}|\\n\\.\\.|[A-Z][A-Z]+\\.{3}|\\nMESOSCALE|$)', 'i'));
+      if (!m) return '';
+      return m[0].replace(new RegExp('^' + label + '\\.{3}', 'i'), '').replace(/\s+/g, ' ').trim();
+   };
+   return {
+      area: getInline('AREA AFFECTED'), concerning: getInline('CONCERNING'),
+      valid: getInline('VALID'), summary: getBlock('SUMMARY'),
+      discussion: getBlock('DISCUSSION'), probability: getInline('PROBABILITY OF WATCH ISSUANCE'),
+      raw: text
+   };
+}
+
+function parseIssuedTime(rawText) {
+   if (!rawText) return null;
+   const match = rawText.match(/(\d{2})(\d{2})\s+(AM|PM)\s+(CST|CDT|MST|MDT|EST|EDT|PST|PDT)\s+([A-Z][a-z]{2})\s+([A-Z][a-z]{2})\s+(\d{1,2})\s+(\d{4})/i);
+   if (!match) return null;
+   let hour = parseInt(match[1]);
+   const minute = parseInt(match[2]);
+   const ampm = match[3].toUpperCase();
+   if (ampm === 'PM' && hour !== 12) hour += 12;
+   if (ampm === 'AM' && hour === 12) hour = 0;
+   const months = {'Jan':0,'Feb':1,'Mar':2,'Apr':3,'May':4,'Jun':5,'Jul':6,'Aug':7,'Sep':8,'Oct':9,'Nov':10,'Dec':11};
+   const tzOffsets = {'CST':6,'CDT':5,'MST':7,'MDT':6,'EST':5,'EDT':4,'PST':8,'PDT':7};
+   const offset = tzOffsets[match[4].toUpperCase()] || 0;
+   const utcHour = (hour + offset) % 24;
+   const utcDay = utcHour < hour ? parseInt(match[7]) + 1 : parseInt(match[7]);
+   const date = new Date(Date.UTC(parseInt(match[8]), months[match[6]], utcDay, utcHour, minute, 0));
+   return isNaN(date.getTime()) ? null : date;
+}
+
+function formatDateToLocal(dateObj) {
+   if (!dateObj || isNaN(dateObj.getTime())) return 'Not specified';
+   return dateObj.toLocaleString(undefined, {
+      year:'numeric', month:'short', day:'numeric',
+      hour:'numeric', minute:'2-digit', second:'2-digit', timeZoneName:'short'
+   });
+}
+
+function clearWarningLayers() {
+   warningLayers.forEach(layer => { if (map.hasLayer(layer)) map.removeLayer(layer); });
+   warningLayers = [];
+}
+
+function updateStats(data) {
+   console.log('updateStats called with counter:', data.counter);
+   if (data.updatedAtUTC) updateLastUpdatedTime(data.updatedAtUTC);
+   updateWarningTypeCounts();
+   updateHeaderWrapState();
+}
+
+function updateWarningTypeCounts() {
+   const counts = { tornado: 0, tstorm: 0, tornadoWatch: 0, watch: 0, sps: 0, emergency: 0, pds: 0 };
+   warningsData.forEach(w => {
+      if (!w.type) return;
+      if (w.tier === 'emergency') counts.emergency++;
+      else if (w.tier === 'pds') counts.pds++;
+      const t = w.type.toLowerCase();
+      if (t.includes('tornado warning')) counts.tornado++;
+      else if (t.includes('thunderstorm warning')) counts.tstorm++;
+      else if (t.includes('tornado watch')) counts.tornadoWatch++;
+      else if (t.includes('watch')) counts.watch++;
+      else if (t.includes('special weather statement')) counts.sps++;
+   });
+   
+   const tornadoEl = document.getElementById('count-tornado');
+   const tstormEl = document.getElementById('count-tstorm');
+   const tornadoWatchEl = document.getElementById('count-tornado-watch');
+   const watchEl = document.getElementById('count-watch');
+   const spsEl = document.getElementById('count-sps');
+   if (tornadoEl) tornadoEl.textContent = counts.tornado;
+   if (tstormEl) tstormEl.textContent = counts.tstorm;
+   if (tornadoWatchEl) tornadoWatchEl.textContent = counts.tornadoWatch;
+   if (watchEl) watchEl.textContent = counts.watch;
+   if (spsEl) spsEl.textContent = counts.sps;
+   const emergencyEl = document.getElementById('count-emergency');
+   const pdsEl = document.getElementById('count-pds');
+   if (emergencyEl) emergencyEl.textContent = counts.emergency;
+   if (pdsEl) pdsEl.textContent = counts.pds;
+   
+   const tornadoStatusEl = document.querySelector('.status-item.tornado');
+   const tstormStatusEl = document.querySelector('.status-item.tstorm');
+   const tornadoWatchStatusEl = document.querySelector('.status-item.tornado-watch');
+   const watchStatusEl = document.querySelector('.status-item.watch');
+   const spsStatusEl = document.querySelector('.status-item.sps');
+   if (tornadoStatusEl) tornadoStatusEl.classList.toggle('active', counts.tornado > 0);
+   if (tstormStatusEl) tstormStatusEl.classList.toggle('active', counts.tstorm > 0);
+   if (tornadoWatchStatusEl) tornadoWatchStatusEl.classList.toggle('active', counts.tornadoWatch > 0);
+   if (watchStatusEl) watchStatusEl.classList.toggle('active', counts.watch > 0);
+   if (spsStatusEl) spsStatusEl.classList.toggle('active', counts.sps > 0);
+   const emergencyStatusEl = document.querySelector('.status-item.emergency');
+   const pdsStatusEl = document.querySelector('.status-item.pds');
+   if (emergencyStatusEl) emergencyStatusEl.classList.toggle('active', counts.emergency > 0);
+   if (pdsStatusEl) pdsStatusEl.classList.toggle('active', counts.pds > 0);
+   
+    const mcdCountEl = document.getElementById('mcd-count');
+    const mcdStatusEl = document.getElementById('mcd-status');
+    if (validMCDs) {
+       if (mcdCountEl) mcdCountEl.textContent = validMCDs.length;
+       if (mcdStatusEl) {
+          if (validMCDs.length > 0) {
+             mcdStatusEl.classList.add('active');
+          } else {
+             mcdStatusEl.classList.remove('active');
+          }
+       }
+     }
+     
+     const totalCount = warningsData.length + (validMCDs ? validMCDs.length : 0);
+     const tabMapCount = document.getElementById('tab-map-count');
+     const tabListCount = document.getElementById('tab-list-count');
+     if (tabMapCount) tabMapCount.textContent = warningsData.length;
+     if (tabListCount) tabListCount.textContent = totalCount;
+  }
+  
+   function switchTab(tab) {
+      const mainContainer = document.querySelector('.main-container');
+      const tabMap = document.getElementById('tab-map');
+      const tabList = document.getElementById('tab-list');
+      if (!mainContainer || !tabMap || !tabList) return;
+      
+      mainContainer.classList.remove('show-map', 'show-list');
+      mainContainer.classList.add('show-' + tab);
+      tabMap.classList.toggle('active', tab === 'map');
+      tabList.classList.toggle('active', tab === 'list');
+      
+      if (tab === 'map' && map) {
+         setTimeout(() => {
+            if (map) map.invalidateSize();
+         }, 100);
+      }
+      
+      if (tab === 'list') {
+         const listSection = document.getElementById('warnings-list');
+         if (listSection && listSection.innerHTML === '') {
+            updateListView(warningsData);
+         }
+      }
+   }
+
+ function addMesoscaleDiscussionsToList() {
+   const container = document.getElementById('mcd-cards-container');
+   const mcdSection = container ? container.closest('.mcd-section') : null;
+   if (!container) return;
+
+   const isMobile = window.innerWidth <= 600;
+   if (isMobile) return;
+
+   validMCDs = mesoscaleDiscussions.filter(mcd => extractMCDNumber(mcd.properties || {}) !== '????');
+
+   const mcdCountEl = document.getElementById('mcd-count');
+   if (mcdCountEl) mcdCountEl.textContent = validMCDs.length;
+   const mcdStatusEl = document.getElementById('mcd-status');
+   if (mcdStatusEl) {
+      if (validMCDs.length > 0) {
+         mcdStatusEl.classList.add('active');
+      } else {
+         mcdStatusEl.classList.remove('active');
+      }
+   }
+
+   if (validMCDs.length === 0) { 
+      if (mcdSection) mcdSection.style.display = 'none';
+      return; 
+   }
+   
+   if (mcdSection) mcdSection.style.display = 'block';
+
+   let html = '';
+   validMCDs.forEach((mcd, index) => {
+      const props = mcd.properties || {};
+      const mcdNum = extractMCDNumber(props);
+      const parsed = parseMCDText(props._fullText || '');
+      const issuedDate = parseIssuedTime(props._fullText);
+      const issued = issuedDate ? formatDateToLocal(issuedDate) : (props.idp_filedate ? formatArcGISDate(props.idp_filedate) : 'Not specified');
+      const spcUrl = mcdSPCLink(mcdNum);
+      const expire = extractExpireTime(parsed.valid);
+
+      html += '<div class="mcd-card" onclick="zoomToMCD(' + index + ')">';
+      html += '<div class="mcd-card-header">';
+      html += '<span class="mcd-num">MCD #' + mcdNum + '</span>';
+      if (parsed.probability) html += '<span class="powi">POWI: ' + escapeHtml(parsed.probability) + '</span>';
+      html += '</div>';
+      if (parsed.area) html += '<div class="mcd-card-area">' + escapeHtml(parsed.area.substring(0, 60)) + (parsed.area.length > 60 ? '...' : '') + '</div>';
+      if (parsed.concerning) html += '<div class="mcd-card-concerning">' + escapeHtml(parsed.concerning) + '</div>';
+      html += '<a href="' + spcUrl + '" target="_blank" class="mcd-card-link" onclick="event.stopPropagation();">View on SPC ↗</a>';
+      html += '</div>';
+   });
+   container.innerHTML = html;
+   updateAllExpirationCountdowns();
+}
+
+function escapeHtml(str) {
+   return String(str).replace(/&/g,'&amp;').replace(/</g,'&lt;').replace(/>/g,'&gt;').replace(/"/g,'&quot;');
+}
+
+function zoomToMCD(index) {
+    const isMobile = window.innerWidth <= 600;
+    if (isMobile) {
+       switchTab('map');
+       map.invalidateSize();
+    }
+    
+    const mcd = validMCDs[index];
+    if (!mcd || !mcd.geometry) return;
+    const mcdNum = extractMCDNumber(mcd.properties || {});
+    const doZoom = () => {
+       try {
+          let bounds;
+          if (mcd.geometry.type === 'Polygon') {
+             bounds = L.latLngBounds(mcd.geometry.coordinates[0].map(c => [c[1],c[0]]));
+          } else if (mcd.geometry.type === 'MultiPolygon') {
+             bounds = L.latLngBounds(mcd.geometry.coordinates.flat(1).map(c => [c[1],c[0]]));
+          }
+          if (bounds && bounds.isValid()) {
+             map.fitBounds(bounds, { padding:[50,50] });
+             setTimeout(() => {
+                const layer = warningLayers.find(l => l._popup && l._popup._content && l._popup._content.includes('MCD #' + mcdNum));
+                if (layer) layer.openPopup();
+             }, isMobile ? 600 : 500);
+          }
+       } catch (e) { console.error('Error zooming to MCD:', e); }
+    };
+    if (isMobile) {
+       setTimeout(doZoom, 150);
+    } else {
+       doZoom();
+    }
+}
+
+function getSeverityClassJS(severity) {
+   if (!severity) return 'other';
+   const s = severity.toLowerCase();
+   if (s==='extreme'||s==='severe') return 'severe';
+   if (s==='moderate') return 'moderate';
+   return 'other';
+}
+
+function getWarningSeverityClass(warning) {
+   if (warning.tier) return warning.tier;
+   const t = (warning.type || '').toLowerCase();
+   if (t.includes('tornado warning')) return 'tornado';
+   if (t.includes('tornado') && t.includes('watch')) return 'tornado-watch';
+   if ((t.includes('thunderstorm')||t.includes('t-storm')||t.includes('tstorm')) && t.includes('watch')) return 'watch';
+   if (t.includes('thunderstorm warning')||t.includes('t-storm warning')||t.includes('tstorm warning')) return 'tstorm';
+   if (t.includes('special weather statement')) return 'sps';
+   return getSeverityClassJS(warning.severity);
+}
+
+function parseISOTime(isoString) {
+   if (!isoString) return '';
+   try {
+      return new Date(isoString).toLocaleString(undefined, {
+         year:'numeric', month:'short', day:'numeric',
+         hour:'numeric', minute:'2-digit', second:'2-digit', timeZoneName:'short'
+      });
+   } catch (e) { return isoString; }
+}
+
+function getExpiresTimestampJS(isoString) {
+   if (!isoString) return '';
+   try { return Math.floor(new Date(isoString).getTime() / 1000); } catch(e) { return ''; }
+}
+
+// getHazardRank mirrors the Go ranking: 4 catastrophic, 3 destructive or
+// considerable tornado, 2 considerable thunderstorm, 1 observed tornado.
+function getHazardRank(w) {
+   if (w.tornadoDamageThreat === 'CATASTROPHIC') return 4;
+   if (w.tornadoDamageThreat === 'CONSIDERABLE' || w.thunderstormDamageThreat === 'DESTRUCTIVE') return 3;
+   if (w.thunderstormDamageThreat === 'CONSIDERABLE') return 2;
+   if (w.tornadoDetection === 'OBSERVED') return 1;
+   return 0;
+}
+
+function getHazardClass(w) {
+   return ['', 'hazard-observed', 'hazard-considerable', 'hazard-destructive', 'hazard-catastrophic'][getHazardRank(w)];
+}
+
+function renderHazardTags(w) {
+   const tags = [];
+   if (w.tornadoDetection) tags.push('Tornado ' + w.tornadoDetection.toLowerCase());
+   if (w.tornadoDamageThreat) tags.push(w.tornadoDamageThreat.toLowerCase() + ' tornado damage');
+   if (w.thunderstormDamageThreat) tags.push(w.thunderstormDamageThreat.toLowerCase() + ' damage');
+   if (w.maxHailSize) tags.push('Hail ' + w.maxHailSize.toFixed(2) + '"');
+   if (w.maxWindGust) tags.push('Wind ' + w.maxWindGust + ' mph');
+   if (tags.length === 0) return '';
+   return '<div class="hazard-tags">' + tags.map(t => '<span class="hazard-tag">' + escapeHtml(t) + '</span>').join('') + '</div>';
+}
+
+function renderWarningCard(warning) {
+   const severityClass = getWarningSeverityClass(warning);
+   const expiresTimestamp = getExpiresTimestampJS(warning.expiresTime);
+   const flash = flashUntil[warning.id] > Date.now() ? ' flash' : '';
+   return '<div class="warning-card ' + severityClass + ' ' + getHazardClass(warning) + flash + '" data-warning-id="' + warning.id + '">' +
+      '<div class="warning-card-header ' + severityClass + '">' +
+         '<h3 onclick="zoomToWarning(\'' + warning.id + '\')">' + (warning.type||'') + renderLifecycleBadge(warning) + '</h3>' +
+         '<span class="severity-badge">' + (warning.severity||'') + '</span>' +
+      '</div>' +
+      '<div class="area">' + (warning.area||'') + '</div>' +
+      renderHazardTags(warning) +
+      (warning.senderName ? '<div class="sender">' + escapeHtml(warning.senderName) +
+         (warning.messageType && warning.messageType !== 'Alert' ? ' · ' + escapeHtml(warning.messageType) : '') + '</div>' : '') +
+      '<div class="description">' + (warning.description||'') + '</div>' +
+      (warning.instruction ? '<div class="instruction"><strong>Instructions:</strong> ' + escapeHtml(warning.instruction) + '</div>' : '') +
+      '<div class="times">' +
+         '<span>Expires: ' + parseISOTime(warning.expiresTime) + '</span>' +
+         '<span class="expiration-countdown" data-expires-timestamp="' + expiresTimestamp + '"></span>' +
+      '</div>' +
+   '</div>';
+}
+
+// getDisplayType mirrors the Go grouping: emergencies and PDS products
+// are listed in their own groups ahead of ordinary warnings.
+function getDisplayType(w) {
+   if (w.tier === 'emergency') return (w.type||'').includes(' Warning') ? w.type.replace(' Warning', ' Emergency') : w.type + ' Emergency';
+   if (w.tier === 'pds') return 'PDS ' + w.type;
+   return w.type;
+}
+
+function getTierRank(tier) {
+   return tier === 'emergency' ? 2 : tier === 'pds' ? 1 : 0;
+}
+
+function renderWarningsList(warnings) {
+   const byType = {};
+   warnings.forEach(w => { const dt = getDisplayType(w); if (!byType[dt]) byType[dt]=[]; byType[dt].push(w); });
+   const typeOrder = ['Tornado Warning','Severe Thunderstorm Warning','Tornado Watch','Severe Thunderstorm Watch','Flash Flood Warning','Special Weather Statement'];
+   const sortedTypes = Object.keys(byType).sort((a,b) => {
+      const ta = getTierRank(byType[a][0].tier), tb = getTierRank(byType[b][0].tier);
+      if (ta!==tb) return tb-ta;
+      const ia = typeOrder.indexOf(byType[a][0].type), ib = typeOrder.indexOf(byType[b][0].type);
+      if (ia!==-1&&ib!==-1) return ia-ib;
+      if (ia!==-1) return -1; if (ib!==-1) return 1;
+      return a.localeCompare(b);
+   });
+   
+   if (sortedTypes.length === 0) {
+      return '<div class="no-warnings">No active weather warnings</div>';
+   }
+   
+    let html = '';
+    sortedTypes.forEach(type => {
+       const sc = getWarningSeverityClass(byType[type][0]);
+       const sorted = [...byType[type]].sort((a,b) =>
+          getHazardRank(b) - getHazardRank(a) || new Date(a.expiresTime||0) - new Date(b.expiresTime||0));
+       html += '<div class="warning-type-header ' + sc + '" id="' + encodeURIComponent(type) + '"><h2>' + type + ' (' + byType[type].length + ')</h2></div>';
+       sorted.forEach(w => { html += renderWarningCard(w); });
+    });
+   return html;
+}
+
+function updateListView(warnings) {
+   const listSection = document.getElementById('warnings-list');
+   if (!listSection) return;
+   
+   let html = '';
+   const isMobile = window.innerWidth <= 600;
+   
+   if (isMobile && validMCDs && validMCDs.length > 0) {
+      html += '<div class="warning-type-header mcd" id="mcd-header"><h2>Mesoscale Discussions (' + validMCDs.length + ')</h2></div>';
+      validMCDs.forEach((mcd, index) => {
+         const props = mcd.properties || {};
+         const mcdNum = extractMCDNumber(props);
+         const parsed = parseMCDText(props._fullText || '');
+         const spcUrl = mcdSPCLink(mcdNum);
+         html += '<div class="warning-card mcd" onclick="zoomToMCD(' + index + ')">';
+         html += '<div class="warning-card-header mcd">';
+         html += '<h3>MCD #' + mcdNum + '</h3>';
+         if (parsed.probability) html += '<span class="severity-badge mcd">' + escapeHtml(parsed.probability) + '</span>';
+         html += '</div>';
+         if (parsed.area) html += '<div class="area">' + escapeHtml(parsed.area.substring(0, 80)) + (parsed.area.length > 80 ? '...' : '') + '</div>';
+         if (parsed.concerning) html += '<div class="mcd-card-concerning">' + escapeHtml(parsed.concerning) + '</div>';
+         html += '<a href="' + spcUrl + '" target="_blank" class="mcd-card-link" onclick="event.stopPropagation();">View on SPC ↗</a>';
+         html += '</div>';
+      });
+      
+       const mcdCountEl = document.getElementById('mcd-count');
+       const mcdStatusEl = document.getElementById('mcd-status');
+       if (mcdCountEl) mcdCountEl.textContent = validMCDs.length;
+       if (mcdStatusEl) {
+          mcdStatusEl.classList.add('active');
+       }
+       
+       const totalCount = warnings.length + validMCDs.length;
+       const tabListCount = document.getElementById('tab-list-count');
+       if (tabListCount) tabListCount.textContent = totalCount;
+    }
+   
+   if (warnings.length === 0 && (!validMCDs || validMCDs.length === 0)) {
+      listSection.innerHTML = '<div class="no-warnings">No active weather warnings</div>' + renderEndedList();
+   } else if (warnings.length === 0) {
+      listSection.innerHTML = html + renderEndedList();
+   } else {
+      listSection.innerHTML = html + renderWarningsList(warnings) + renderEndedList();
+   }
+   updateAllExpirationCountdowns();
+}
+
+function formatLocalTime(timestamp) {
+   return new Date(timestamp * 1000).toLocaleString(undefined, {
+      year:'numeric', month:'short', day:'numeric',
+      hour:'numeric', minute:'2-digit', second:'2-digit', timeZoneName:'short'
+   });
+}
+
+function updateLastUpdatedTime(timestamp) {
+   const el = document.getElementById('last-updated-time');
+   if (el && timestamp) el.textContent = formatLocalTime(timestamp);
+}
+
+
+function updateHeaderWrapState() {
+   const statusSummary = document.querySelector('.status-summary');
+   if (!statusSummary) return;
+   
+   const isMobile = window.innerWidth <= 600;
+   if (isMobile) {
+      document.body.classList.remove('header-wrapped');
+      return;
+   }
+   
+   const items = statusSummary.querySelectorAll('.status-item');
+   if (items.length < 2) return;
+   
+   const firstItem = items[0];
+   const firstItemRect = firstItem.getBoundingClientRect();
+   
+   let hasWrapped = false;
+   for (let i = 1; i < items.length; i++) {
+      const itemRect = items[i].getBoundingClientRect();
+      if (itemRect.top > firstItemRect.top + 2) {
+         hasWrapped = true;
+         break;
+      }
+   }
+   
+   document.body.classList.toggle('header-wrapped', hasWrapped);
+}
+
+let resizeTimer;
+window.addEventListener('resize', function() {
+   clearTimeout(resizeTimer);
+   resizeTimer = setTimeout(updateHeaderWrapState, 100);
+});
+
+window.onload = function() {
+   updateHeaderWrapState();
+   renderAlertSettings();
+   initMap();
+
+   const initialTimestamp = DASHBOARD.updatedAtUTC;
+   if (initialTimestamp) updateLastUpdatedTime(initialTimestamp);
+
+   const refreshInterval = 15000;
+   let refreshTime = 15;
+   const countdownElements = document.querySelectorAll('.countdown');
+   
+   const isMobile = window.innerWidth <= 600;
+   if (isMobile) {
+      setTimeout(() => {
+         if (map) map.invalidateSize();
+      }, 500);
+   }
+   
+   function updateCountdown() {
+      countdownElements.forEach(el => el.textContent = streamOpen ? 'live' : refreshTime + 's');
+   }
+   
+   setInterval(function() {
+      refreshTime--;
+      if (refreshTime <= 0 && !streamOpen) {
+         countdownElements.forEach(el => el.textContent = '...');
+      } else {
+         updateCountdown();
+      }
+   }, 1000);
+   updateCountdown();
+
+setInterval(function() {
+        refreshTime = 15;
+        updateCountdown();
+        if (!streamOpen) fetchUpdatedWarnings();
+}, refreshInterval);
+     fetchUpdatedWarnings();
+     connectStream();
+
+    updateAllExpirationCountdowns();
+
+   setInterval(function() {
+      const now = serverNow();
+      const before = warningsData.length;
+      warningsData = warningsData.filter(w => !w.expiresTime || new Date(w.expiresTime).getTime() > now);
+      if (warningsData.length !== before) {
+         console.log('[prune] removed ' + (before - warningsData.length) + ' expired warning(s)');
+         clearWarningLayers();
+         addMesoscaleDiscussionsToMap();
+         addWarningsToMap();
+         bringSevereToFront();
+         updateListView(warningsData);
+         updateWarningTypeCounts();
+      }
+      updateAllExpirationCountdowns();
+   }, 1000);
+};
+
+function zoomToWarning(warningId) {
+    const isMobile = window.innerWidth <= 600;
+    if (isMobile) {
+       switchTab('map');
+       map.invalidateSize();
+    }
+    
+    const warning = warningsData.find(w => w.id === warningId);
+    if (!warning) return;
+    const layer = warningLayers.find(l => l._popup && l._popup._content &&
+       l._popup._content.includes(warning.type) && l._popup._content.includes(warning.area));
+    const doZoom = () => {
+       if (layer && layer.getBounds) {
+          map.fitBounds(layer.getBounds(), { padding:[50,50] });
+          setTimeout(() => layer.openPopup(), isMobile ? 600 : 500);
+       } else if (warning.geometry && warning.geometry.coordinates) {
+          try {
+             const coords = warning.geometry.type === 'Polygon'
+                ? warning.geometry.coordinates[0]
+                : warning.geometry.coordinates[0][0];
+             map.fitBounds(L.latLngBounds(coords.map(c => [c[1],c[0]])), { padding:[50,50] });
+          } catch(e) { console.error('Error zooming to warning:', e); }
+       }
+    };
+    if (isMobile) {
+       setTimeout(doZoom, 150);
+    } else {
+       doZoom();
+    }
+}
+
+// createRadarLayer builds the radar overlay: a WMS layer, a single XYZ
+// layer, or, when the server lists timestamped frames, a group with one
+// layer per frame that the radar player steps through.
+function createRadarLayer(radar) {
+   if (radar.frames) {
+      const group = L.featureGroup();
+      radarAnim.group = group;
+      loadRadarFrames();
+      setInterval(loadRadarFrames, 60000);
+      setInterval(stepRadar, 600);
+      const player = L.control.radarPlayer({ position: 'bottomleft' }).addTo(map);
+      map.on('overlayadd overlayremove', function(e) {
+         if (e.layer === group) player.getContainer().style.display = e.type === 'overlayadd' ? '' : 'none';
+      });
+      return group;
+   }
+   const options = {
+      opacity: 0.6,
+      maxZoom: 20,
+      maxNativeZoom: radar.maxNativeZoom || undefined,
+      attribution: radar.attribution
+   };
+   let layer;
+   if (radar.wms) {
+      layer = L.tileLayer.wms(radar.url, Object.assign({
+         layers: radar.layers,
+         format: 'image/png',
+         transparent: true
+      }, options));
+   } else {
+      layer = L.tileLayer(radar.url, options);
+   }
+   layer.on('tileerror', function(e) {
+      console.error('[radar] tile error:', e);
+      setTimeout(function() {
+         if (typeof layer.redraw === 'function') {
+            layer.redraw();
+         }
+      }, 2000);
+   });
+   layer.on('load', function() {
+      console.log('[radar] tiles loaded successfully');
+   });
+   return layer;
+}
+
+// Every radar frame stays loaded at zero opacity, so stepping through them
+// only changes which one shows and the loop doesn't wait on tiles.
+const radarAnim = { group: null, frames: [], layers: {}, index: -1, playing: true, hold: 0, label: null, button: null };
+
+async function loadRadarFrames() {
+   try {
+      const response = await fetch(DASHBOARD.radar.frames, { cache: 'no-cache' });
+      if (!response.ok) throw new Error('Failed to read radar frames: ' + response.status);
+      const frames = (await response.json()).frames || [];
+      const layers = {};
+      frames.forEach(f => {
+         layers[f.key] = radarAnim.layers[f.key] || L.tileLayer(DASHBOARD.radar.url.replace('{frame}', f.key), {
+            opacity: 0, maxZoom: 20, maxNativeZoom: DASHBOARD.radar.maxNativeZoom || undefined,
+            attribution: DASHBOARD.radar.attribution
+         });
+         radarAnim.group.addLayer(layers[f.key]);
+      });
+      Object.keys(radarAnim.layers).forEach(key => {
+         if (!layers[key]) radarAnim.group.removeLayer(radarAnim.layers[key]);
+      });
+      radarAnim.frames = frames;
+      radarAnim.layers = layers;
+      if (!radarAnim.playing || radarAnim.index < 0 || radarAnim.index >= frames.length) {
+         radarAnim.index = frames.length - 1;
+      }
+      showRadarFrame(radarAnim.index);
+   } catch (error) {
+      console.error('[radar] error reading frames:', error);
+   }
+}
+
+function showRadarFrame(index) {
+   radarAnim.index = index;
+   radarAnim.frames.forEach((f, i) => radarAnim.layers[f.key].setOpacity(i === index ? 0.6 : 0));
+   const frame = radarAnim.frames[index];
+   if (radarAnim.label && frame) {
+      radarAnim.label.textContent = new Date(frame.time).toLocaleTimeString(undefined, { hour:'numeric', minute:'2-digit', timeZoneName:'short' });
+   }
+}
+
+// stepRadar advances the loop, holding on the newest frame for a moment
+// so the current picture is easy to read.
+function stepRadar() {
+   if (!radarAnim.playing || radarAnim.frames.length < 2 || !map.hasLayer(radarAnim.group)) return;
+   const last = radarAnim.frames.length - 1;
+   if (radarAnim.index === last && ++radarAnim.hold < 4) return;
+   radarAnim.hold = 0;
+   showRadarFrame(radarAnim.index >= last ? 0 : radarAnim.index + 1);
+}
+
+L.Control.RadarPlayer = L.Control.extend({
+   onAdd: function() {
+      const div = L.DomUtil.create('div', 'leaflet-control-radar-player');
+      const button = L.DomUtil.create('button', '', div);
+      button.textContent = '❚❚';
+      button.title = 'Pause the radar loop';
+      const label = L.DomUtil.create('span', '', div);
+      radarAnim.button = button;
+      radarAnim.label = label;
+      L.DomEvent.disableClickPropagation(div);
+      button.onclick = function() {
+         radarAnim.playing = !radarAnim.playing;
+         button.textContent = radarAnim.playing ? '❚❚' : '▶';
+         button.title = radarAnim.playing ? 'Pause the radar loop' : 'Play the radar loop';
+         if (!radarAnim.playing) showRadarFrame(radarAnim.frames.length - 1);
+      };
+      return div;
+   }
+});
+L.control.radarPlayer = opts => new L.Control.RadarPlayer(opts);
+
+async function initMap() {
+   localStorage.removeItem('mapState');
+   map = L.map('map', { zoomControl: true });
+   resetMapView();
+
+   if (DASHBOARD.basemap) {
+      L.tileLayer(DASHBOARD.basemap.url, {
+         attribution: DASHBOARD.basemap.attribution,
+         subdomains: 'abcd', maxZoom: 20,
+         minZoom: DASHBOARD.basemap.minZoom || 0,
+         maxNativeZoom: DASHBOARD.basemap.maxNativeZoom || undefined
+      }).addTo(map);
+   }
+
+  if (DASHBOARD.radar) {
+     radarLayer = createRadarLayer(DASHBOARD.radar);
+     radarLayer.addTo(map);
+     const overlays = { "Radar": radarLayer };
+     L.control.layers(null, overlays, { collapsed: false, autoZIndex: false }).addTo(map);
+  }
+
+    L.Control.ResetMap = L.Control.extend({
+       onAdd: function(map) {
+          const btn = L.DomUtil.create('button', 'leaflet-control-reset-map');
+          btn.innerHTML = '⟲ Reset';
+          btn.title = initialBounds ? 'Reset to region view' : 'Reset to full US view';
+          btn.onclick = function(e) {
+             L.DomEvent.stopPropagation(e);
+             resetMapView();
+             localStorage.removeItem('mapState');
+          };
+          return btn;
+       }
+    });
+    L.control.resetMap = opts => new L.Control.ResetMap(opts);
+    L.control.resetMap({ position: 'topright' }).addTo(map);
+    map.on('moveend', saveMapState);
+    map.on('zoomend', saveMapState);
+
+    addWarningsToMap();
+    updateListView(warningsData);
+}
+
+function resetMapView() {
+   if (initialBounds) {
+      map.fitBounds(initialBounds, { padding:[20,20] });
+   } else {
+      map.setView([39.8283, -98.5795], 4);
+   }
+}
+
+function saveMapState() {
+   const c = map.getCenter();
+   localStorage.setItem('mapState', JSON.stringify({ lat: c.lat, lng: c.lng, zoom: map.getZoom() }));
+}
+
+function addMesoscaleDiscussionsToMap() {
+   if (!mesoscaleDiscussions || mesoscaleDiscussions.length === 0) return;
+   validMCDs = mesoscaleDiscussions.filter(mcd => extractMCDNumber(mcd.properties || {}) !== '????');
+   if (validMCDs.length === 0) return;
+
+   validMCDs.forEach((mcd, index) => {
+      if (!mcd.geometry) return;
+      try {
+         const props = mcd.properties || {};
+         const mcdNum = extractMCDNumber(props);
+         const parsed = parseMCDText(props._fullText || '');
+         const issuedDate = parseIssuedTime(props._fullText);
+         const issued = issuedDate ? formatDateToLocal(issuedDate) : (props.idp_filedate ? formatArcGISDate(props.idp_filedate) : 'Not specified');
+         const spcUrl = mcdSPCLink(mcdNum);
+         const expire = extractExpireTime(parsed.valid);
+
+         const geoJsonLayer = L.geoJSON(mcd, {
+             style: { color:'#00FFFF', fillColor:'#00FFFF', fillOpacity:0.15, weight:2, opacity:0.9, dashArray:'10, 5' }
+          }).addTo(map);
+          geoJsonLayer.on('click', function(e) {
+             L.DomEvent.stopPropagation(e);
+             geoJsonLayer.openPopup();
+          });
+          warningLayers.push(geoJsonLayer);
+
+         let bodyHtml = '';
+         if (parsed.area||parsed.concerning||parsed.summary||parsed.discussion||parsed.probability) {
+            if (parsed.area) bodyHtml += '<p style="margin:3px 0;"><strong>Area:</strong> ' + escapeHtml(parsed.area) + '</p>';
+            if (parsed.concerning) bodyHtml += '<p style="margin:3px 0;"><strong>Concerning:</strong> ' + escapeHtml(parsed.concerning) + '</p>';
+            if (parsed.summary) bodyHtml += '<div style="margin-top:8px;padding-top:8px;border-top:1px solid #ccc;"><strong>Summary:</strong><p style="margin:4px 0 0;font-size:0.9em;max-height:160px;overflow-y:auto;line-height:1.4;">' + escapeHtml(parsed.summary) + '</p></div>';
+            if (parsed.discussion) bodyHtml += '<div style="margin-top:8px;padding-top:8px;border-top:1px solid #ccc;"><strong>Discussion:</strong><p style="margin:4px 0 0;font-size:0.85em;max-height:200px;overflow-y:auto;line-height:1.4;">' + escapeHtml(parsed.discussion) + '</p></div>';
+         } else if (props._fullText) {
+            const pt = props._fullText.replace(/\s+/g,' ').trim();
+            if (pt) bodyHtml = '<p style="font-size:0.9em;max-height:200px;overflow-y:auto;">' + escapeHtml(pt.substring(0,800)) + (pt.length>800?'…':'') + '</p>';
+         } else {
+            const pt = (props.popupinfo||'').replace(/<[^>]+>/g,' ').replace(/\s+/g,' ').trim();
+            if (pt) bodyHtml = '<p style="font-size:0.9em;max-height:200px;overflow-y:auto;">' + escapeHtml(pt.substring(0,800)) + (pt.length>800?'…':'') + '</p>';
+         }
+
+         let popupContent = '<div style="min-width:200px;max-width:280px;font-size:13px;">' +
+            '<h3 style="margin:0 0 6px 0;font-size:14px;color:#006666;">MCD #' + mcdNum + '</h3>' +
+            '<p style="margin:3px 0;"><strong>Issued:</strong> ' + escapeHtml(issued) + '</p>';
+         if (expire) popupContent += '<p style="margin:3px 0;"><strong>Expires:</strong> ' + escapeHtml(formatExpireToLocal(expire, props._fullText)) + '</p>';
+         if (parsed.probability) popupContent += '<p style="margin:3px 0;color:#ff6600;font-weight:bold;">POWI: ' + escapeHtml(parsed.probability) + '</p>';
+         popupContent += bodyHtml + '<p style="margin-top:8px;padding-top:8px;border-top:1px solid #ccc;"><a href="' + spcUrl + '" target="_blank" style="color:#006666;font-size:12px;">View on SPC ↗</a></p></div>';
+
+         geoJsonLayer.bindPopup(popupContent, { maxWidth:300, maxHeight:350 });
+         geoJsonLayer.bindTooltip('MCD #' + mcdNum + (parsed.concerning?' — '+parsed.concerning:'') + (parsed.area?' ('+parsed.area.substring(0,60)+(parsed.area.length>60?'…':'')+')':''), { sticky:true });
+         geoJsonLayer.on('mouseover', e => e.target.setStyle({ fillOpacity:0.3, weight:3 }));
+         geoJsonLayer.on('mouseout',  e => e.target.setStyle({ fillOpacity:0.15, weight:2 }));
+      } catch(error) { console.error('Error adding MCD to map:', error); }
+   });
+}
+
+function getWarningColor(warningType, severity, tier) {
+   if (tier === 'emergency') return '#FF00FF';
+   if (tier === 'pds') return '#FF6A00';
+   const t = (warningType||'').toLowerCase();
+   if (t.includes('tornado warning')) return '#FF1493';
+   if (t.includes('tornado')&&t.includes('watch')) return '#FFFF00';
+   if (t.includes('thunderstorm warning')||t.includes('t-storm warning')||t.includes('tstorm warning')) return '#FF0000';
+   if ((t.includes('thunderstorm')||t.includes('t-storm')||t.includes('tstorm'))&&t.includes('watch')) return '#FFA500';
+   if (t.includes('special weather statement')) return '#66B2FF';
+   if (severity==='Severe'||severity==='Extreme') return '#FF4444';
+   if (severity==='Moderate') return '#FFAA00';
+   return '#666666';
+}
+
+function addWarningsToMap() {
+   let added=0, skipped=0;
+    const valid = warningsData.filter(w => w && w.severity !== 'Header' && w.geometry && w.geometry.type);
+     const order = ['tornado warning','severe thunderstorm warning','tornado watch','severe thunderstorm watch','flash flood warning','special weather statement'];
+     valid.sort((a,b) => {
+       const at=(a.type||'').toLowerCase(), bt=(b.type||'').toLowerCase();
+       let ai=order.length, bi=order.length;
+       order.forEach((o,i) => { if(at.includes(o)) ai=i; if(bt.includes(o)) bi=i; });
+       if (ai!==bi) return ai-bi;
+       return getTierRank(a.tier)-getTierRank(b.tier) || getHazardRank(a)-getHazardRank(b) || new Date(a.expiresTime||0)-new Date(b.expiresTime||0);
+    });
+   valid.forEach(warning => {
+      const color = getWarningColor(warning.type, warning.severity, warning.tier);
+      try {
+         if (warning.geometry.type==='Polygon') { drawPolygon(warning.geometry.coordinates, warning, color); added++; }
+         else if (warning.geometry.type==='MultiPolygon') { warning.geometry.coordinates.forEach(pc=>drawPolygon(pc,warning,color)); added++; }
+         else skipped++;
+      } catch(e) { console.error('Error adding warning to map:', warning.type, e); skipped++; }
+   });
+   console.log('Map: ' + added + ' added, ' + skipped + ' skipped');
+}
+
+function bringSevereToFront() {
+   warningLayers.forEach(l => { if (l.warningSeverity==='Severe') l.bringToFront(); });
+}
+
+function drawPolygon(coordinates, warning, color) {
+   const latLngs = coordinates.map(ring => ring.map(c => [c[1],c[0]]));
+   const hazard = getHazardRank(warning);
+   const style = { color, fillColor:color, fillOpacity:0.3, weight:2, opacity:0.8 };
+   if (hazard >= 3) Object.assign(style, { weight:5, opacity:1, fillOpacity:0.45, className:'hazard-polygon' });
+   else if (hazard >= 1) Object.assign(style, { weight:3, opacity:0.95 });
+   // Outlines built from the alert's counties and zones are dashed so
+   // they read differently from a polygon the NWS drew.
+   if (warning.geometryDerived) Object.assign(style, { fillOpacity:0.15, dashArray:'5, 5' });
+   const polygon = L.polygon(latLngs, style).addTo(map);
+   polygon.warningSeverity = warning.severity;
+   warningLayers.push(polygon);
+    const popup = '<div style="min-width:200px;max-width:280px;font-size:13px;">' +
+       '<h3 style="margin:0 0 8px 0;font-size:14px;">' + (warning.type||'Unknown') + '</h3>' +
+      '<p style="margin:3px 0;"><strong>Severity:</strong> ' + (warning.severity||'Unknown') + '</p>' +
+      '<p style="margin:3px 0;"><strong>Area:</strong> ' + (warning.area||'Unknown') + '</p>' +
+      renderHazardTags(warning) +
+      '<p style="margin:3px 0;"><strong>Expires:</strong> ' + formatTime(warning.expiresTime) + '</p>' +
+      (warning.senderName?'<p style="margin:3px 0;"><strong>Issued by:</strong> ' + escapeHtml(warning.senderName) + '</p>':'') +
+      (warning.description?'<div style="margin-top:8px;padding-top:8px;border-top:1px solid #ccc;font-size:12px;"><strong>Details:</strong><p style="margin:4px 0 0;font-size:11px;max-height:80px;overflow-y:auto;line-height:1.3;">' + warning.description + '</p></div>':'') +
+      (warning.geometryDerived?'<p style="font-style:italic;font-size:11px;margin-top:8px;padding-top:8px;border-top:1px solid #ccc;">⚠️ Outline of the listed counties and zones</p>':'') +
+      '</div>';
+   polygon.bindPopup(popup, { maxWidth:300, maxHeight:250 });
+   polygon.bindTooltip((warning.type||'Warning') + ' - ' + (warning.area||'Unknown area') + (warning.geometryDerived?' (zones)':''), { sticky:true });
+}
+
+function formatTime(t) {
+   if (!t) return 'Not specified';
+   const d = new Date(t);
+   return isNaN(d.getTime()) ? t : d.toLocaleString();
+}
+
+function updateAllExpirationCountdowns() {
+   document.querySelectorAll('[data-expires-timestamp]').forEach(el => {
+      const ts = parseInt(el.getAttribute('data-expires-timestamp'));
+      if (!ts) return;
+      const left = ts - Math.floor(serverNow()/1000);
+      if (left <= 0) {
+         el.textContent = 'EXPIRED';
+         el.classList.remove('ok', 'warning');
+         el.classList.add('urgent');
+      } else {
+         const h=Math.floor(left/3600), m=Math.floor((left%3600)/60), s=left%60;
+         el.textContent = (h>0?h+'h ':'') + m+'m '+s+'s';
+         el.classList.remove('urgent', 'warning');
+         if (left<1800) el.classList.add('urgent');
+         else if (left<7200) el.classList.add('warning');
+         else el.classList.add('ok');
+      }
+   });
+}
@@ -0,0 +1,160 @@
+// Package web is the dashboard's front end: the page templates under
+// templates/ and the stylesheet, script and optional Leaflet copy under
+// static/. They are embedded in the binary, which serves them itself.
+// Leaflet is only among them once go generate has fetched it; until then
+// pages load it from unpkg.com, and the default basemap comes from CARTO
+// unless the tile proxy or an MBTiles file serves it.
+package web
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"html/template"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+//go:generate go run ./fetchleaflet -o static/leaflet
+
+//go:embed templates static
+var embedded embed.FS
+
+// LeafletCDN is where the page loads Leaflet from when no copy is bundled
+// and no other location is configured.
+const LeafletCDN = "https://unpkg.com/leaflet@1.9.4/dist/"
+
+// DefaultBasemapURL is the CARTO dark basemap the map is drawn on.
+const DefaultBasemapURL = "https://{s}.basemaps.cartocdn.com/dark_all/{z}/{x}/{y}{r}.png"
+
+// Options choose where the page's front end comes from.
+type Options struct {
+	// Dir, when set, is a directory laid out like this package, with
+	// templates/ and static/, used instead of the built-in files. Pages are
+	// re-rendered from it on every update, so the front end can be edited
+	// without rebuilding.
+	Dir string `yaml:"dir"`
+	// LeafletURL is the base URL of a Leaflet 1.9 dist directory, e.g. an
+	// internal mirror. By default the page uses the copy in static/leaflet
+	// when one is bundled, and unpkg.com otherwise.
+	LeafletURL string `yaml:"leafletURL"`
+	// BasemapURL is the XYZ tile URL template of the map background, or
+	// "none" for a plain background. Defaults to DefaultBasemapURL.
+	BasemapURL string `yaml:"basemapURL"`
+	// BasemapAttribution is shown in the map corner for a custom basemap.
+	BasemapAttribution string `yaml:"basemapAttribution"`
+}
+
+// Files returns the front end: the built-in files, or those in opts.Dir.
+func (o Options) Files() fs.FS {
+	if o.Dir != "" {
+		return os.DirFS(o.Dir)
+	}
+	return embedded
+}
+
+// Basemap returns the tile layer the page draws under the alerts, or nil
+// when the basemap is turned off.
+func (o Options) Basemap() *Basemap {
+	switch o.BasemapURL {
+	case "none":
+		return nil
+	case "":
+		return &Basemap{URL: DefaultBasemapURL, Attribution: "&copy; OpenStreetMap &copy; CARTO"}
+	}
+	return &Basemap{URL: o.BasemapURL, Attribution: o.BasemapAttribution}
+}
+
+// LeafletBase returns the base URL the page loads leaflet.js and
+// leaflet.css from. The bundled copy is only used by pages the built-in
+// server hosts, since a page written to disk has no static/ beside it.
+func (o Options) LeafletBase(served bool) string {
+	if o.LeafletURL != "" {
+		return strings.TrimSuffix(o.LeafletURL, "/") + "/"
+	}
+	if served {
+		if _, err := fs.Stat(o.Files(), "static/leaflet/leaflet.js"); err == nil {
+			return "static/leaflet/"
+		}
+	}
+	return LeafletCDN
+}
+
+// Basemap is an XYZ tile layer.
+type Basemap struct {
+	URL         string `json:"url"`
+	Attribution string `json:"attribution"`
+	// MinZoom and MaxNativeZoom bound the zooms the tiles exist at, for
+	// tile sets that don't cover every zoom. Beyond MaxNativeZoom the map
+	// scales up the deepest tiles.
+	MinZoom       int `json:"minZoom,omitempty"`
+	MaxNativeZoom int `json:"maxNativeZoom,omitempty"`
+}
+
+// Radar is the radar overlay: a WMS endpoint drawn with Layers, or an XYZ
+// tile URL. When Frames is set, it is the URL of a list of timestamped
+// frames, and the page animates through them by substituting each frame's
+// key for {frame} in URL.
+type Radar struct {
+	URL         string `json:"url"`
+	WMS         bool   `json:"wms,omitempty"`
+	Layers      string `json:"layers,omitempty"`
+	Frames      string `json:"frames,omitempty"`
+	Attribution string `json:"attribution"`
+	// MaxNativeZoom is the deepest zoom tiles are requested at; the map
+	// scales them up beyond it.
+	MaxNativeZoom int `json:"maxNativeZoom,omitempty"`
+}
+
+// Page is what the page template renders.
+type Page struct {
+	// LastUpdated is the time shown in the header until the script runs.
+	LastUpdated string
+	// Config is the JSON object the script reads as DASHBOARD: the initial
+	// alerts, the source clock offset, the map's initial bounds, basemap
+	// and radar.
+	Config template.JS
+	// LeafletURL is the base URL of leaflet.js and leaflet.css.
+	LeafletURL string
+	// Inline pages carry their stylesheet and script in CSS and JS; others
+	// link to static/, with Version appended to bust browser caches.
+	Inline  bool
+	CSS     template.CSS
+	JS      template.JS
+	Version string
+}
+
+// ParseTemplates parses the page templates in files.
+func ParseTemplates(files fs.FS) (*template.Template, error) {
+	return template.New("page.html").ParseFS(files, "templates/*.html")
+}
+
+// Assets returns the stylesheet and script in files, for inline pages.
+func Assets(files fs.FS) (template.CSS, template.JS, error) {
+	css, err := fs.ReadFile(files, "static/dashboard.css")
+	if err != nil {
+		return "", "", err
+	}
+	js, err := fs.ReadFile(files, "static/dashboard.js")
+	if err != nil {
+		return "", "", err
+	}
+	return template.CSS(css), template.JS(js), nil
+}
+
+// Version is a short hash of the stylesheet and script in files.
+func Version(files fs.FS) string {
+	h := sha256.New()
+	for _, name := range []string{"static/dashboard.css", "static/dashboard.js"} {
+		data, _ := fs.ReadFile(files, name)
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:6])
+}
+
+// Static returns the static/ tree of files, as the server exposes it under
+// /static/.
+func Static(files fs.FS) (fs.FS, error) {
+	return fs.Sub(files, "static")
+}
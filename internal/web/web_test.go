@@ -0,0 +1,83 @@
+package web
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPageRenders(t *testing.T) {
+	files := Options{}.Files()
+	tmpl, err := ParseTemplates(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	css, js, err := Assets(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, inline := range []bool{false, true} {
+		page := Page{
+			LastUpdated: "Jan 2, 2006 at 15:04 UTC",
+			Config:      `{"warnings":[],"updatedAtUTC":1136214245}`,
+			LeafletURL:  LeafletCDN,
+			Inline:      inline,
+			Version:     Version(files),
+		}
+		if inline {
+			page.CSS, page.JS = css, js
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, page); err != nil {
+			t.Fatalf("inline=%v: %v", inline, err)
+		}
+		out := buf.String()
+		for _, want := range []string{
+			`const DASHBOARD = {"warnings":[],"updatedAtUTC":1136214245};`,
+			`<script src="` + LeafletCDN + `leaflet.js"></script>`,
+			`<span id="last-updated-time">Jan 2, 2006 at 15:04 UTC</span>`,
+			`id="warnings-list"`,
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("inline=%v: page lacks %s", inline, want)
+			}
+		}
+		linked := strings.Contains(out, `src="static/dashboard.js?v=`+page.Version+`"`)
+		if linked == inline {
+			t.Errorf("inline=%v: page links static/dashboard.js: %v", inline, linked)
+		}
+		if inline && !strings.Contains(out, "function addWarningsToMap") {
+			t.Error("inline page lacks the script")
+		}
+	}
+}
+
+func TestLeafletBase(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{Dir: dir}
+	if got := opts.LeafletBase(true); got != LeafletCDN {
+		t.Errorf("without a bundled copy: got %q, want %q", got, LeafletCDN)
+	}
+
+	path := filepath.Join(dir, "static", "leaflet", "leaflet.js")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("/* leaflet */"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := opts.LeafletBase(true); got != "static/leaflet/" {
+		t.Errorf("served with a bundled copy: got %q", got)
+	}
+	if got := opts.LeafletBase(false); got != LeafletCDN {
+		t.Errorf("written to disk: got %q, want %q", got, LeafletCDN)
+	}
+
+	opts.LeafletURL = "https://mirror.example/leaflet"
+	if got := opts.LeafletBase(true); got != "https://mirror.example/leaflet/" {
+		t.Errorf("with LeafletURL: got %q", got)
+	}
+}